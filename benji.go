@@ -3,7 +3,10 @@ package main
 import (
 	"flag"
 	"fmt"
+	"log"
+	"math/rand"
 	"os"
+	"time"
 
 	"github.com/uzudil/benji4000/bscript"
 	"github.com/uzudil/benji4000/gfx"
@@ -17,14 +20,55 @@ func main() {
 	var source string
 	flag.StringVar(&source, "source", "", "the bscript file to run")
 	showAst := flag.Bool("ast", false, "print AST and not execute?")
+	record := flag.String("record", "", "record the key presses and the random seed into this file")
+	replay := flag.String("replay", "", "replay the key presses and the random seed from this file")
+	clock := flag.String("clock", "real", "real: getTicks() is the real time, stepped: it moves by -step per frame (always stepped when recording or replaying)")
+	border := flag.Int("border", gfx.DefaultBorder, "the size of the border around the display, in pixels")
+	step := flag.Float64("step", 1000.0/60, "how many milliseconds the stepped clock moves per frame (a replay uses the recording's)")
 	flag.Parse()
 
-	video := gfx.NewGfx()
+	video := gfx.NewGfx(gfx.WithBorder(*border, *border))
+
+	switch {
+	case *clock == "stepped" || *record != "" || *replay != "":
+		video.Clock = gfx.NewSteppedClock(*step / 1000)
+	case *clock != "real":
+		log.Fatalf("unknown clock %q, use real or stepped", *clock)
+	}
+
+	seed := time.Now().UnixNano()
+	if *replay != "" {
+		r, err := os.Open(*replay)
+		if err != nil {
+			log.Fatal(err)
+		}
+		var recordedStep float64
+		seed, recordedStep, err = video.Render.Replay(r)
+		r.Close()
+		if err != nil {
+			log.Fatal(err)
+		}
+		if recordedStep > 0 {
+			// the clock has to move like it did when recording
+			video.Clock = gfx.NewSteppedClock(recordedStep)
+		}
+	} else if *record != "" {
+		w, err := os.Create(*record)
+		if err != nil {
+			log.Fatal(err)
+		}
+		if err := video.Render.Record(w, seed, *step/1000); err != nil {
+			log.Fatal(err)
+		}
+	}
+	rand.Seed(seed)
 
 	if source != "" {
 		go func() {
 			_, err := bscript.Run(source, showAst, nil, video)
-			if err != nil {
+			if runtimeError, ok := err.(*bscript.RuntimeError); ok {
+				fmt.Print(runtimeError.Trace())
+			} else if err != nil {
 				fmt.Printf("Error: %v\n", err)
 			}
 			os.Exit(0)
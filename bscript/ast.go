@@ -3,7 +3,6 @@ package bscript
 import (
 	"github.com/alecthomas/participle"
 	"github.com/alecthomas/participle/lexer"
-	"github.com/alecthomas/participle/lexer/ebnf"
 
 	"strings"
 )
@@ -12,15 +11,27 @@ type Program struct {
 	Pos lexer.Position
 
 	TopLevel []*TopLevel `( @@ )*`
+
+	// all the comments in the source
+	Comments []*Remark
 }
 
 type TopLevel struct {
 	Pos lexer.Position
 
-	Remark *Remark `(  @@ `
-	Let    *Let    `| @@ ";"`
+	Let    *Let    `(  @@ ";"`
 	Const  *Const  `| @@ ";"`
+	Struct *Struct `| @@`
 	Fun    *Fun    `| @@ )`
+
+	Comments []*Remark
+}
+
+type Struct struct {
+	Pos lexer.Position
+
+	Name   string   `"struct" @Ident "{"`
+	Fields []string `( @Ident ( "," @Ident )* )? "}"`
 }
 
 type Const struct {
@@ -34,30 +45,53 @@ type Fun struct {
 	Pos lexer.Position
 
 	Name     string     `"def" @Ident "("`
-	Params   []string   `( @Ident ( "," @Ident )* )*`
+	Params   []*Param   `( @@ ( "," @@ )* )*`
 	Commands []*Command `")" "{" ( @@ )* "}"`
 }
 
+// Param is a function parameter: a name, a name with a default value (b = 2) or a rest parameter (...rest)
+type Param struct {
+	Pos lexer.Position
+
+	Rest    bool        `@"..."?`
+	Name    string      `@Ident`
+	Default *Expression `( "=" @@ )?`
+}
+
+// AnonParam is a parameter of an anonymous function. Unlike Param it can't have a default value:
+// the parser could not tell (a = 1) => a from the comparison (a = 1) early enough.
+type AnonParam struct {
+	Pos lexer.Position
+
+	Rest bool   `@"..."?`
+	Name string `@Ident`
+}
+
 type AnonFun struct {
 	Pos lexer.Position
 
-	Params        []string    `( "(" ( @Ident ( "," @Ident )* )* ")" "=" ">"`
-	SingleParam   *string     `| @Ident "=" ">" )`
-	Commands      []*Command  `( "{" ( @@ )* "}"`
-	SingleCommand *Expression `| @@ )`
+	Params        []*AnonParam `( "(" ( @@ ( "," @@ )* )* ")" "=" ">"`
+	SingleParam   *string      `| @Ident "=" ">" )`
+	Commands      []*Command   `( "{" ( @@ )* "}"`
+	SingleCommand *Expression  `| @@ )`
 }
 
 type Command struct {
 	Pos lexer.Position
 
-	Remark *Remark `(   @@ `
-	Let    *Let    `  | @@ ";" `
+	Let    *Let    `(   @@ ";" `
 	Del    *Del    `  | @@ ";" `
 	Return *Return `  | @@ ";" `
 	If     *If     `  | @@ `
+	Switch *Switch `  | @@ `
 	While  *While  `  | @@ `
+	Try    *Try    `  | @@ `
+	Throw  *Throw  `  | @@ ";" `
+	Yield  *Yield  `  | @@ ";" `
 	Fun    *Fun    `  | @@ `
 	Call   *Call   `  | @@ ";" )`
+
+	Comments []*Remark
 }
 
 type Del struct {
@@ -78,13 +112,40 @@ type If struct {
 
 	Condition    *Expression `"if" "(" @@ ")" "{"`
 	Commands     []*Command  `( @@ )* "}"`
-	ElseCommands []*Command  `( "else" "{" ( @@ )* "}" )?`
+	ElseIf       *If         `( "else" ( @@ `
+	ElseCommands []*Command  `| "{" ( @@ )* "}" ) )?`
+}
+
+type Switch struct {
+	Pos lexer.Position
+
+	Value           *Expression `"switch" "(" @@ ")" "{"`
+	Cases           []*Case     `( @@ )*`
+	DefaultCommands []*Command  `( "default" ":" ( @@ )* )? "}"`
+}
+
+type Case struct {
+	Pos lexer.Position
+
+	Values   []*Expression `"case" @@ ( "," @@ )* ":"`
+	Commands []*Command    `( @@ )*`
 }
 
-type Remark struct {
+type Try struct {
 	Pos lexer.Position
 
-	Comment string `@Comment`
+	Commands      []*Command `"try" "{" ( @@ )* "}"`
+	CatchVariable *string    `( "catch" "(" @Ident ")" "{"`
+	CatchCommands []*Command `( @@ )* "}" )?`
+	// an empty finally block has no commands, so this tells if there is one
+	Finally         bool       `( @"finally" "{"`
+	FinallyCommands []*Command `( @@ )* "}" )?`
+}
+
+type Throw struct {
+	Pos lexer.Position
+
+	Value *Expression `"throw" @@`
 }
 
 type Call struct {
@@ -97,7 +158,15 @@ type Call struct {
 type CallParams struct {
 	Pos lexer.Position
 
-	Args []*Expression `"(" [ @@ { "," @@ } ] ")"`
+	Args []*Arg `"(" [ @@ { "," @@ } ] ")"`
+}
+
+// Arg is an argument in a function call. A spread argument (...args) passes the elements of an array as arguments.
+type Arg struct {
+	Pos lexer.Position
+
+	Spread bool        `@"..."?`
+	Value  *Expression `@@`
 }
 
 type Let struct {
@@ -105,7 +174,8 @@ type Let struct {
 
 	ArrayElement *ArrayElement `( @@ `
 	Variable     *string       `| @Ident )`
-	Value        *Expression   `":" "=" @@`
+	Operator     Operator      `( ":" "=" | @( "+" | "-" | "*" | "/" | "%" ) "=" )`
+	Value        *Expression   `@@`
 }
 
 type Return struct {
@@ -114,6 +184,12 @@ type Return struct {
 	Value *Expression `"return" @@`
 }
 
+type Yield struct {
+	Pos lexer.Position
+
+	Value *Expression `"yield" @@?`
+}
+
 type Operator string
 
 func (o *Operator) Capture(s []string) error {
@@ -124,24 +200,24 @@ func (o *Operator) Capture(s []string) error {
 type Value struct {
 	Pos lexer.Position
 
-	Array         *Array        ` @@`
-	Map           *Map          `| @@`
-	AnonFun       *AnonFun      `| @@`
-	Null          *string       `| @"null"`
-	Number        *SignedNumber `| @@`
-	Boolean       *string       `| @("true" | "false")`
-	Call          *Call         `| @@`
-	ArrayElement  *ArrayElement `| @@`
-	Variable      *Variable     `| @@`
-	String        *string       `| @String`
-	Subexpression *Expression   `| "(" @@ ")"`
+	Array         *Array         ` @@`
+	Map           *Map           `| @@`
+	AnonFun       *AnonFun       `| @@`
+	Null          *string        `| @"null"`
+	Number        *SignedNumber  `| @@`
+	Boolean       *string        `| @("true" | "false")`
+	Call          *Call          `| @@`
+	ArrayElement  *ArrayElement  `| @@`
+	Variable      *Variable      `| @@`
+	String        *StringLiteral `| @String`
+	Subexpression *Expression    `| "(" @@ ")"`
 }
 
 type SignedNumber struct {
 	Pos lexer.Position
 
-	Sign   *string `@("+" | "-")?`
-	Number float64 `@Number`
+	Sign   *string        `@("+" | "-")?`
+	Number *NumberLiteral `@Number`
 }
 
 type Variable struct {
@@ -159,7 +235,8 @@ type ArrayElement struct {
 
 type ArrayIndex struct {
 	Pos   lexer.Position
-	Index *Expression `"[" @@ "]"`
+	Index *Expression `(  "[" @@ "]"`
+	Field *string     ` | "." @Ident )`
 }
 
 type Array struct {
@@ -179,28 +256,38 @@ type Map struct {
 type NameValuePair struct {
 	Pos lexer.Position
 
-	Name  string      `@String ":"`
-	Value *Expression `@@`
+	Name  *StringLiteral `@String ":"`
+	Value *Expression    `@@`
+
+	Comments []*Remark
 }
 
 type Factor struct {
 	Pos lexer.Position
 
 	Base     *Value `@@`
-	Exponent *Value `[ "^" @@ ]`
+	Exponent *Unary `[ "*" "*" @@ ]`
+}
+
+type Unary struct {
+	Pos lexer.Position
+
+	Operator Operator `( @("!" | "-" | "~")`
+	Unary    *Unary   `  @@`
+	Factor   *Factor  `| @@ )`
 }
 
 type OpFactor struct {
 	Pos lexer.Position
 
-	Operator Operator `@("*" | "/" | "%")`
-	Factor   *Factor  `@@`
+	Operator Operator `@("*" | "/" "/" | "/" | "%")`
+	Unary    *Unary   `@@`
 }
 
 type Term struct {
 	Pos lexer.Position
 
-	Left  *Factor     `@@`
+	Left  *Unary      `@@`
 	Right []*OpFactor `{ @@ }`
 }
 
@@ -218,59 +305,142 @@ type Cmp struct {
 	Right []*OpTerm `{ @@ }`
 }
 
+type OpShift struct {
+	Pos lexer.Position
+
+	Operator Operator `@("<" "<" | ">" ">")`
+	Cmp      *Cmp     `@@`
+}
+
+type Shift struct {
+	Pos lexer.Position
+
+	Left  *Cmp       `@@`
+	Right []*OpShift `{ @@ }`
+}
+
+type OpBitAnd struct {
+	Pos lexer.Position
+
+	Operator Operator `@"&"`
+	Shift    *Shift   `@@`
+}
+
+type BitAnd struct {
+	Pos lexer.Position
+
+	Left  *Shift      `@@`
+	Right []*OpBitAnd `{ @@ }`
+}
+
+type OpBitXor struct {
+	Pos lexer.Position
+
+	Operator Operator `@"^"`
+	BitAnd   *BitAnd  `@@`
+}
+
+type BitXor struct {
+	Pos lexer.Position
+
+	Left  *BitAnd     `@@`
+	Right []*OpBitXor `{ @@ }`
+}
+
+type OpBitOr struct {
+	Pos lexer.Position
+
+	Operator Operator `@"|"`
+	BitXor   *BitXor  `@@`
+}
+
+type BitOr struct {
+	Pos lexer.Position
+
+	Left  *BitXor    `@@`
+	Right []*OpBitOr `{ @@ }`
+}
+
 type OpCmp struct {
 	Pos lexer.Position
 
 	Operator Operator `@("=" | "<" "=" | ">" "=" | "<" | ">" | "!" "=")`
-	Cmp      *Cmp     `@@`
+	BitOr    *BitOr   `@@`
 }
 
 type BoolTerm struct {
-	Left  *Cmp     `@@`
+	Left  *BitOr   `@@`
 	Right []*OpCmp `{ @@ }`
 }
 
-type OpBoolTerm struct {
+type OpAndTerm struct {
+	Pos lexer.Position
+
+	Operator Operator  `@("&" "&")`
+	BoolTerm *BoolTerm `@@`
+}
+
+type AndTerm struct {
 	Pos lexer.Position
 
-	Operator Operator  `@("&" "&" | "|" "|")`
-	Right    *BoolTerm `@@`
+	Left  *BoolTerm    `@@`
+	Right []*OpAndTerm `{ @@ }`
+}
+
+type OpOrTerm struct {
+	Pos lexer.Position
+
+	Operator Operator `@("|" "|")`
+	AndTerm  *AndTerm `@@`
+}
+
+type OrTerm struct {
+	Pos lexer.Position
+
+	Left  *AndTerm    `@@`
+	Right []*OpOrTerm `{ @@ }`
 }
 
 type Expression struct {
 	Pos lexer.Position
 
-	BoolTerm   *BoolTerm     `@@`
-	OpBoolTerm []*OpBoolTerm `{ @@ }`
+	Condition *OrTerm     `@@`
+	IfTrue    *Expression `[ "?" @@`
+	IfFalse   *Expression `  ":" @@ ]`
+
+	Comments []*Remark
 }
 
 var (
-	benjiLexer = lexer.Must(ebnf.New(`
-		Comment = "#" { "\u0000"…"\uffff"-"\n"-"\r" } .
-		Ident = (alpha | "_") { "_" | alpha | digit } .
-		String = "\"" { "\u0000"…"\uffff"-"\""-"\\" | "\\" any } "\"" .
-		Number = ("." | digit) { "." | digit } .
-		Punct = "!"…"/" | ":"…"@" | "["…` + "\"`\"" + ` | "{"…"~" .
-		Whitespace = ( " " | "\t" | "\n" | "\r" ) { " " | "\t" | "\n" | "\r" } .
-
-		alpha = "a"…"z" | "A"…"Z" .
-		digit = "0"…"9" .
-		any = "\u0000"…"\uffff" .
-	`))
+	// comments are # to the end of the line, or #[ between brackets ]# which can span lines.
+	// strings are either "quoted" or """triple-quoted""" and can span lines.
+	// A quoted string can contain ${...} expressions, which may contain (simple) quoted strings.
+	benjiLexer = lexer.Must(lexer.Regexp(`(?P<Comment>#\[(?s:.*?)\]#|#[^\n\r]*)` +
+		`|(?P<Ident>[a-zA-Z_][a-zA-Z0-9_]*)` +
+		`|(?P<String>"""(?s:.*?)"""|"(?:[^"\\$]|\\(?s:.)|\$\{(?:[^{}"]|"(?:[^"\\]|\\(?s:.))*")*\}|\$)*")` +
+		`|(?P<Number>0[xX][0-9a-fA-F]+|[0-9]*\.?[0-9]+)` +
+		`|(?P<Punct>\.\.\.|[!-/:-@[-` + "`" + `{-~])` +
+		`|(?P<Whitespace>[ \t\n\r]+)`))
 
 	Parser = participle.MustBuild(&Program{},
 		participle.Lexer(benjiLexer),
 		participle.CaseInsensitive("Ident"),
-		participle.Unquote("String"),
 		participle.UseLookahead(8),
-		participle.Elide("Whitespace"),
+		participle.Elide("Whitespace", "Comment"),
 	)
 
 	CommandParser = participle.MustBuild(&Command{},
 		participle.Lexer(benjiLexer),
 		participle.CaseInsensitive("Ident"),
-		participle.Unquote("String"),
 		participle.UseLookahead(8),
-		participle.Elide("Whitespace"),
+		participle.Elide("Whitespace", "Comment"),
+	)
+
+	// ExpressionParser parses the ${...} expressions inside strings
+	ExpressionParser = participle.MustBuild(&Expression{},
+		participle.Lexer(benjiLexer),
+		participle.CaseInsensitive("Ident"),
+		participle.UseLookahead(8),
+		participle.Elide("Whitespace", "Comment"),
 	)
 )
@@ -4,6 +4,8 @@ import (
 	"fmt"
 	"math"
 	"math/rand"
+	"os"
+	"path/filepath"
 	"strings"
 
 	"github.com/go-gl/glfw/v3.2/glfw"
@@ -12,8 +14,7 @@ import (
 
 func print(ctx *Context, arg ...interface{}) (interface{}, error) {
 	ctx.Video.Println(EvalString(arg[0]), true)
-	ctx.Video.UpdateVideo()
-	return nil, nil
+	return nil, ctx.updateVideo("print")
 }
 
 func trace(ctx *Context, arg ...interface{}) (interface{}, error) {
@@ -22,44 +23,59 @@ func trace(ctx *Context, arg ...interface{}) (interface{}, error) {
 }
 
 func getTicks(ctx *Context, arg ...interface{}) (interface{}, error) {
-	return ctx.Video.Render.GetTicks(), nil
+	return ctx.Video.GetTicks(), nil
 }
 
 func input(ctx *Context, arg ...interface{}) (interface{}, error) {
 	ctx.Video.Println(EvalString(arg[0]), false)
-	ctx.Video.UpdateVideo()
+	if err := ctx.updateVideo("input"); err != nil {
+		return nil, err
+	}
 
 	var text strings.Builder
+	typed := func(char rune) {
+		if char == 9 {
+			if text.Len() > 0 {
+				// try to remove it from the screen
+				err := ctx.Video.Backspace()
+				if err == nil {
+					// remove the last character from memory
+					s := text.String()
+					text = strings.Builder{}
+					text.WriteString(s[0 : len(s)-1])
+				} else {
+					fmt.Println("Can't backspace")
+				}
+			}
+		} else {
+			text.WriteRune(char)
+			ctx.Video.Println(string(char), false)
+		}
+	}
 	// start capturing input
 	ctx.Video.Render.StartInput <- 1
 
-	// block until input mode is over
-	for done := false; done != true; {
+	// the typed chars arrive at the end of a frame, so keep showing frames until enter is pressed
+	for done := false; !done; {
+		if err := ctx.updateVideo("input"); err != nil {
+			return nil, err
+		}
+		for len(ctx.Video.Render.CharInput) > 0 {
+			typed(<-ctx.Video.Render.CharInput)
+		}
 		select {
-		case char := <-ctx.Video.Render.CharInput:
-			if char == 9 {
-				if text.Len() > 0 {
-					// try to remove it from the screen
-					err := ctx.Video.Backspace()
-					if err == nil {
-						// remove the last character from memory
-						s := text.String()
-						text = strings.Builder{}
-						text.WriteString(s[0 : len(s)-1])
-					} else {
-						fmt.Println("Can't backspace")
-					}
-				}
-			} else {
-				text.WriteRune(char)
-				ctx.Video.Println(string(char), false)
-			}
 		case <-ctx.Video.Render.StopInput:
 			ctx.Video.Println("", true)
+			if err := ctx.updateVideo("input"); err != nil {
+				return nil, err
+			}
 			done = true
+		default:
+			ctx.Video.Render.WaitFrame()
 		}
-		ctx.Video.UpdateVideo()
 	}
+	// the keys typed were the input, not events for getKey()
+	ctx.Video.Keyboard.ClearKeys()
 	return strings.TrimSpace(text.String()), nil
 }
 
@@ -70,9 +86,9 @@ func length(ctx *Context, arg ...interface{}) (interface{}, error) {
 		if !ok {
 			return nil, fmt.Errorf("argument to len() should be an array or a string")
 		}
-		return float64(len(s)), nil
+		return len(s), nil
 	}
-	return float64(len(*a)), nil
+	return len(*a), nil
 }
 
 func substr(ctx *Context, arg ...interface{}) (interface{}, error) {
@@ -80,13 +96,13 @@ func substr(ctx *Context, arg ...interface{}) (interface{}, error) {
 	if !ok {
 		return nil, fmt.Errorf("argument 1 to substr() should be a string")
 	}
-	index, ok := arg[1].(float64)
+	index, ok := floatValue(arg[1])
 	if !ok {
 		return nil, fmt.Errorf("argument 2 to substr() should be a number")
 	}
 	length := len(s)
 	if len(arg) > 2 {
-		f, ok := arg[2].(float64)
+		f, ok := floatValue(arg[2])
 		if !ok {
 			return nil, fmt.Errorf("argument 3 to substr() should be a number")
 		}
@@ -129,7 +145,7 @@ func keys(ctx *Context, arg ...interface{}) (interface{}, error) {
 }
 
 func setVideoMode(ctx *Context, arg ...interface{}) (interface{}, error) {
-	mode, ok := arg[0].(float64)
+	mode, ok := floatValue(arg[0])
 	if !ok {
 		return nil, fmt.Errorf("First parameter should be the number of the video mode")
 	}
@@ -138,11 +154,11 @@ func setVideoMode(ctx *Context, arg ...interface{}) (interface{}, error) {
 }
 
 func scroll(ctx *Context, arg ...interface{}) (interface{}, error) {
-	dx, ok := arg[0].(float64)
+	dx, ok := floatValue(arg[0])
 	if !ok {
 		return nil, fmt.Errorf("First parameter should be a number")
 	}
-	dy, ok := arg[1].(float64)
+	dy, ok := floatValue(arg[1])
 	if !ok {
 		return nil, fmt.Errorf("Second parameter should be a number")
 	}
@@ -151,15 +167,15 @@ func scroll(ctx *Context, arg ...interface{}) (interface{}, error) {
 }
 
 func setPixel(ctx *Context, arg ...interface{}) (interface{}, error) {
-	x, ok := arg[0].(float64)
+	x, ok := floatValue(arg[0])
 	if !ok {
 		return nil, fmt.Errorf("First parameter should be a number")
 	}
-	y, ok := arg[1].(float64)
+	y, ok := floatValue(arg[1])
 	if !ok {
 		return nil, fmt.Errorf("Second parameter should be a number")
 	}
-	color, ok := arg[2].(float64)
+	color, ok := floatValue(arg[2])
 	if !ok {
 		return nil, fmt.Errorf("Third parameter should be a number")
 	}
@@ -167,19 +183,19 @@ func setPixel(ctx *Context, arg ...interface{}) (interface{}, error) {
 }
 
 func drawText(ctx *Context, arg ...interface{}) (interface{}, error) {
-	x, ok := arg[0].(float64)
+	x, ok := floatValue(arg[0])
 	if !ok {
 		return nil, fmt.Errorf("First parameter should be a number")
 	}
-	y, ok := arg[1].(float64)
+	y, ok := floatValue(arg[1])
 	if !ok {
 		return nil, fmt.Errorf("Second parameter should be a number")
 	}
-	fg, ok := arg[2].(float64)
+	fg, ok := floatValue(arg[2])
 	if !ok {
 		return nil, fmt.Errorf("Third parameter should be a number")
 	}
-	bg, ok := arg[3].(float64)
+	bg, ok := floatValue(arg[3])
 	if !ok {
 		return nil, fmt.Errorf("Fourth parameter should be a number")
 	}
@@ -191,47 +207,270 @@ func drawText(ctx *Context, arg ...interface{}) (interface{}, error) {
 }
 
 func drawFont(ctx *Context, arg ...interface{}) (interface{}, error) {
-	x, ok := arg[0].(float64)
+	x, ok := floatValue(arg[0])
 	if !ok {
 		return nil, fmt.Errorf("First parameter should be a number")
 	}
-	y, ok := arg[1].(float64)
+	y, ok := floatValue(arg[1])
 	if !ok {
 		return nil, fmt.Errorf("Second parameter should be a number")
 	}
-	fg, ok := arg[2].(float64)
+	fg, ok := floatValue(arg[2])
 	if !ok {
 		return nil, fmt.Errorf("Third parameter should be a number")
 	}
-	bg, ok := arg[3].(float64)
+	bg, ok := floatValue(arg[3])
 	if !ok {
 		return nil, fmt.Errorf("Fourth parameter should be a number")
 	}
-	ch, ok := arg[4].(float64)
+	ch, ok := floatValue(arg[4])
 	if !ok {
 		return nil, fmt.Errorf("Fifth parameter should be a number")
 	}
 	return nil, ctx.Video.DrawFont(int(x), int(y), rune(ch), uint8(fg), uint8(bg))
 }
 
+func setCellColor(ctx *Context, arg ...interface{}) (interface{}, error) {
+	x, ok := intValue(arg[0])
+	if !ok {
+		return nil, fmt.Errorf("First parameter should be a number")
+	}
+	y, ok := intValue(arg[1])
+	if !ok {
+		return nil, fmt.Errorf("Second parameter should be a number")
+	}
+	fg, ok := intValue(arg[2])
+	if !ok {
+		return nil, fmt.Errorf("Third parameter should be a number")
+	}
+	bg, ok := intValue(arg[3])
+	if !ok {
+		return nil, fmt.Errorf("Fourth parameter should be a number")
+	}
+	return nil, ctx.Video.SetCellColor(x, y, uint8(fg), uint8(bg))
+}
+
+// getCell returns a char on the text screen: { "char": 65, "fg": COLOR_WHITE, "bg": COLOR_BLACK }
+func getCell(ctx *Context, arg ...interface{}) (interface{}, error) {
+	x, ok := intValue(arg[0])
+	if !ok {
+		return nil, fmt.Errorf("First parameter should be a number")
+	}
+	y, ok := intValue(arg[1])
+	if !ok {
+		return nil, fmt.Errorf("Second parameter should be a number")
+	}
+	ch, fg, bg, err := ctx.Video.GetCell(x, y)
+	if err != nil {
+		return nil, err
+	}
+	return map[string]interface{}{
+		"char": int(ch),
+		"fg":   int(fg),
+		"bg":   int(bg),
+	}, nil
+}
+
+func getPixel(ctx *Context, arg ...interface{}) (interface{}, error) {
+	x, ok := intValue(arg[0])
+	if !ok {
+		return nil, fmt.Errorf("First parameter should be a number")
+	}
+	y, ok := intValue(arg[1])
+	if !ok {
+		return nil, fmt.Errorf("Second parameter should be a number")
+	}
+	color, err := ctx.Video.GetPixel(x, y)
+	if err != nil {
+		return nil, err
+	}
+	return int(color), nil
+}
+
+func getChar(ctx *Context, arg ...interface{}) (interface{}, error) {
+	x, ok := intValue(arg[0])
+	if !ok {
+		return nil, fmt.Errorf("First parameter should be a number")
+	}
+	y, ok := intValue(arg[1])
+	if !ok {
+		return nil, fmt.Errorf("Second parameter should be a number")
+	}
+	ch, _, _, err := ctx.Video.GetCell(x, y)
+	if err != nil {
+		return nil, err
+	}
+	return int(ch), nil
+}
+
+// getScreen returns the colors of all pixels in an array: the pixel at x,y is at y * width + x
+func getScreen(ctx *Context, arg ...interface{}) (interface{}, error) {
+	screen := ctx.Video.Screen()
+	a := make([]interface{}, len(screen))
+	for index, color := range screen {
+		a[index] = int(color)
+	}
+	return &a, nil
+}
+
+func peek(ctx *Context, arg ...interface{}) (interface{}, error) {
+	address, ok := intValue(arg[0])
+	if !ok {
+		return nil, fmt.Errorf("argument to peek() should be an address")
+	}
+	value, err := ctx.Video.Memory.Peek(address)
+	if err != nil {
+		return nil, err
+	}
+	return int(value), nil
+}
+
+func poke(ctx *Context, arg ...interface{}) (interface{}, error) {
+	address, ok := intValue(arg[0])
+	if !ok {
+		return nil, fmt.Errorf("First parameter should be an address")
+	}
+	value, ok := intValue(arg[1])
+	if !ok {
+		return nil, fmt.Errorf("Second parameter should be a number")
+	}
+	return nil, ctx.Video.Memory.Poke(address, value)
+}
+
+func setPaletteColor(ctx *Context, arg ...interface{}) (interface{}, error) {
+	rgb := [4]int{}
+	for index := range rgb {
+		value, ok := intValue(arg[index])
+		if !ok {
+			return nil, fmt.Errorf("arguments to setPaletteColor() should be the color's number, then its red, green and blue (0-255)")
+		}
+		rgb[index] = value
+	}
+	for _, value := range rgb[1:] {
+		if value < 0 || value > 255 {
+			return nil, fmt.Errorf("red, green and blue should be 0-255, not %d", value)
+		}
+	}
+	return nil, ctx.Video.SetPaletteColor(rgb[0], uint8(rgb[1]), uint8(rgb[2]), uint8(rgb[3]))
+}
+
+// getPaletteColor returns a color's red, green and blue in an array
+func getPaletteColor(ctx *Context, arg ...interface{}) (interface{}, error) {
+	index, ok := intValue(arg[0])
+	if !ok {
+		return nil, fmt.Errorf("argument to getPaletteColor() should be a color")
+	}
+	r, g, b, err := ctx.Video.GetPaletteColor(index)
+	if err != nil {
+		return nil, err
+	}
+	return &[]interface{}{int(r), int(g), int(b)}, nil
+}
+
+func setPalette(ctx *Context, arg ...interface{}) (interface{}, error) {
+	name, ok := arg[0].(string)
+	if !ok {
+		return nil, fmt.Errorf("argument to setPalette() should be the palette's name")
+	}
+	return nil, ctx.Video.SetPalette(name)
+}
+
+func resetPalette(ctx *Context, arg ...interface{}) (interface{}, error) {
+	ctx.Video.ResetPalette()
+	return nil, nil
+}
+
+func cyclePalette(ctx *Context, arg ...interface{}) (interface{}, error) {
+	from, ok := intValue(arg[0])
+	if !ok {
+		return nil, fmt.Errorf("First parameter should be a color")
+	}
+	to, ok := intValue(arg[1])
+	if !ok {
+		return nil, fmt.Errorf("Second parameter should be a color")
+	}
+	step, ok := intValue(arg[2])
+	if !ok {
+		return nil, fmt.Errorf("Third parameter should be a number")
+	}
+	return nil, ctx.Video.CyclePalette(from, to, step)
+}
+
+func setGlyph(ctx *Context, arg ...interface{}) (interface{}, error) {
+	code, ok := intValue(arg[0])
+	if !ok {
+		return nil, fmt.Errorf("First parameter should be a glyph code")
+	}
+	a, ok := arg[1].(*[]interface{})
+	if !ok || len(*a) != 8 {
+		return nil, fmt.Errorf("Second parameter should be an array of the 8 rows of the glyph")
+	}
+	rows := [8]uint8{}
+	for index, value := range *a {
+		row, ok := intValue(value)
+		if !ok || row < 0 || row > 255 {
+			return nil, fmt.Errorf("the rows of a glyph should be numbers 0-255, not %s", EvalString(value))
+		}
+		rows[index] = uint8(row)
+	}
+	return nil, ctx.Video.SetGlyph(code, rows)
+}
+
+func getGlyph(ctx *Context, arg ...interface{}) (interface{}, error) {
+	code, ok := intValue(arg[0])
+	if !ok {
+		return nil, fmt.Errorf("First parameter should be a glyph code")
+	}
+	rows, err := ctx.Video.GetGlyph(code)
+	if err != nil {
+		return nil, err
+	}
+	a := make([]interface{}, len(rows))
+	for index, row := range rows {
+		a[index] = int(row)
+	}
+	return &a, nil
+}
+
+// loadCharset loads the font from a file: a PNG sheet of 8x8 glyphs, or 8 bytes per glyph. It returns the number of glyphs loaded.
+// A relative path is relative to the program's file.
+func loadCharset(ctx *Context, arg ...interface{}) (interface{}, error) {
+	path, ok := arg[0].(string)
+	if !ok {
+		return nil, fmt.Errorf("argument to loadCharset() should be a file name")
+	}
+	if !filepath.IsAbs(path) && ctx.Pos.Filename != "" {
+		path = filepath.Join(filepath.Dir(ctx.Pos.Filename), path)
+	}
+	f, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+	if strings.HasSuffix(strings.ToLower(path), ".png") {
+		return ctx.Video.LoadCharsetPNG(f)
+	}
+	return ctx.Video.LoadCharset(f)
+}
+
 func drawLine(ctx *Context, arg ...interface{}) (interface{}, error) {
-	x, ok := arg[0].(float64)
+	x, ok := floatValue(arg[0])
 	if !ok {
 		return nil, fmt.Errorf("First parameter should be a number")
 	}
-	y, ok := arg[1].(float64)
+	y, ok := floatValue(arg[1])
 	if !ok {
 		return nil, fmt.Errorf("Second parameter should be a number")
 	}
-	x2, ok := arg[2].(float64)
+	x2, ok := floatValue(arg[2])
 	if !ok {
 		return nil, fmt.Errorf("Third parameter should be a number")
 	}
-	y2, ok := arg[3].(float64)
+	y2, ok := floatValue(arg[3])
 	if !ok {
 		return nil, fmt.Errorf("Fourth parameter should be a number")
 	}
-	color, ok := arg[4].(float64)
+	color, ok := floatValue(arg[4])
 	if !ok {
 		return nil, fmt.Errorf("Fifth parameter should be a number")
 	}
@@ -239,19 +478,19 @@ func drawLine(ctx *Context, arg ...interface{}) (interface{}, error) {
 }
 
 func drawCircle(ctx *Context, arg ...interface{}) (interface{}, error) {
-	x, ok := arg[0].(float64)
+	x, ok := floatValue(arg[0])
 	if !ok {
 		return nil, fmt.Errorf("First parameter should be a number")
 	}
-	y, ok := arg[1].(float64)
+	y, ok := floatValue(arg[1])
 	if !ok {
 		return nil, fmt.Errorf("Second parameter should be a number")
 	}
-	r, ok := arg[2].(float64)
+	r, ok := floatValue(arg[2])
 	if !ok {
 		return nil, fmt.Errorf("Third parameter should be a number")
 	}
-	color, ok := arg[3].(float64)
+	color, ok := floatValue(arg[3])
 	if !ok {
 		return nil, fmt.Errorf("Fourth parameter should be a number")
 	}
@@ -259,19 +498,19 @@ func drawCircle(ctx *Context, arg ...interface{}) (interface{}, error) {
 }
 
 func fillCircle(ctx *Context, arg ...interface{}) (interface{}, error) {
-	x, ok := arg[0].(float64)
+	x, ok := floatValue(arg[0])
 	if !ok {
 		return nil, fmt.Errorf("First parameter should be a number")
 	}
-	y, ok := arg[1].(float64)
+	y, ok := floatValue(arg[1])
 	if !ok {
 		return nil, fmt.Errorf("Second parameter should be a number")
 	}
-	r, ok := arg[2].(float64)
+	r, ok := floatValue(arg[2])
 	if !ok {
 		return nil, fmt.Errorf("Third parameter should be a number")
 	}
-	color, ok := arg[3].(float64)
+	color, ok := floatValue(arg[3])
 	if !ok {
 		return nil, fmt.Errorf("Fourth parameter should be a number")
 	}
@@ -279,32 +518,90 @@ func fillCircle(ctx *Context, arg ...interface{}) (interface{}, error) {
 }
 
 func setBackground(ctx *Context, arg ...interface{}) (interface{}, error) {
-	c, ok := arg[0].(float64)
+	c, ok := floatValue(arg[0])
+	if !ok {
+		return nil, fmt.Errorf("First parameter should be a number")
+	}
+	*ctx.Video.BackgroundColor = byte(c)
+	return nil, nil
+}
+
+func setBorder(ctx *Context, arg ...interface{}) (interface{}, error) {
+	c, ok := floatValue(arg[0])
 	if !ok {
 		return nil, fmt.Errorf("First parameter should be a number")
 	}
-	ctx.Video.BackgroundColor = byte(c)
+	*ctx.Video.BorderColor = byte(c) & 0x0f
 	return nil, nil
 }
 
+func setScrollOffset(ctx *Context, arg ...interface{}) (interface{}, error) {
+	x, ok := floatValue(arg[0])
+	if !ok {
+		return nil, fmt.Errorf("First parameter should be a number")
+	}
+	y, ok := floatValue(arg[1])
+	if !ok {
+		return nil, fmt.Errorf("Second parameter should be a number")
+	}
+	ctx.Video.SetScrollOffset(int(x), int(y))
+	return nil, nil
+}
+
+func getFramePixel(ctx *Context, arg ...interface{}) (interface{}, error) {
+	x, ok := intValue(arg[0])
+	if !ok {
+		return nil, fmt.Errorf("First parameter should be a whole number")
+	}
+	y, ok := intValue(arg[1])
+	if !ok {
+		return nil, fmt.Errorf("Second parameter should be a whole number")
+	}
+	rgb, err := ctx.Video.Render.FramePixel(x, y)
+	if err != nil {
+		return nil, err
+	}
+	a := []interface{}{int(rgb[0]), int(rgb[1]), int(rgb[2])}
+	return &a, nil
+}
+
+func screenshot(ctx *Context, arg ...interface{}) (interface{}, error) {
+	path, ok := arg[0].(string)
+	if !ok {
+		return nil, fmt.Errorf("argument to screenshot() should be a file name")
+	}
+	if !filepath.IsAbs(path) && ctx.Pos.Filename != "" {
+		path = filepath.Join(filepath.Dir(ctx.Pos.Filename), path)
+	}
+	f, err := os.Create(path)
+	if err != nil {
+		return nil, err
+	}
+	if err = ctx.Video.Render.SaveScreenshot(f); err != nil {
+		f.Close()
+		return nil, err
+	}
+	return nil, f.Close()
+}
+
 func fillRect(ctx *Context, arg ...interface{}) (interface{}, error) {
-	x, ok := arg[0].(float64)
+	x, ok := floatValue(arg[0])
 	if !ok {
 		return nil, fmt.Errorf("First parameter should be a number")
 	}
-	y, ok := arg[1].(float64)
+	y, ok := floatValue(arg[1])
 	if !ok {
 		return nil, fmt.Errorf("Second parameter should be a number")
 	}
-	x2, ok := arg[2].(float64)
+	x2, ok := floatValue(arg[2])
 	if !ok {
 		return nil, fmt.Errorf("Third parameter should be a number")
 	}
-	y2, ok := arg[3].(float64)
+	y2, ok := floatValue(arg[3])
 	if !ok {
 		return nil, fmt.Errorf("Fourth parameter should be a number")
 	}
-	color, ok := arg[4].(float64)
+	color, ok := floatValue(arg[4])
 	if !ok {
 		return nil, fmt.Errorf("Fifth parameter should be a number")
 	}
@@ -312,23 +609,23 @@ func fillRect(ctx *Context, arg ...interface{}) (interface{}, error) {
 }
 
 func drawRect(ctx *Context, arg ...interface{}) (interface{}, error) {
-	x, ok := arg[0].(float64)
+	x, ok := floatValue(arg[0])
 	if !ok {
 		return nil, fmt.Errorf("First parameter should be a number")
 	}
-	y, ok := arg[1].(float64)
+	y, ok := floatValue(arg[1])
 	if !ok {
 		return nil, fmt.Errorf("Second parameter should be a number")
 	}
-	x2, ok := arg[2].(float64)
+	x2, ok := floatValue(arg[2])
 	if !ok {
 		return nil, fmt.Errorf("Third parameter should be a number")
 	}
-	y2, ok := arg[3].(float64)
+	y2, ok := floatValue(arg[3])
 	if !ok {
 		return nil, fmt.Errorf("Fourth parameter should be a number")
 	}
-	color, ok := arg[4].(float64)
+	color, ok := floatValue(arg[4])
 	if !ok {
 		return nil, fmt.Errorf("Fifth parameter should be a number")
 	}
@@ -339,12 +636,79 @@ func clearVideo(ctx *Context, arg ...interface{}) (interface{}, error) {
 	return nil, ctx.Video.ClearVideo()
 }
 
+// updateVideo shows the video memory. updateVideo(true) waits until it's on the screen, instead of pacing itself to the frame rate.
 func updateVideo(ctx *Context, arg ...interface{}) (interface{}, error) {
 	if ctx.Video == nil {
 		panic("Video card not initialized")
 	}
-	// todo: delay here to achive a requested max framerate (default to 60)
-	return nil, ctx.Video.UpdateVideo()
+	wait := false
+	if len(arg) > 0 {
+		var ok bool
+		if wait, ok = arg[0].(bool); !ok {
+			return nil, fmt.Errorf("argument to updateVideo() should be true or false")
+		}
+	}
+	if !wait {
+		ctx.paceFrame()
+	}
+	if err := ctx.runCoroutines(); err != nil {
+		return nil, err
+	}
+	if err := ctx.updateVideo("updateVideo"); err != nil {
+		return nil, err
+	}
+	if wait {
+		ctx.Video.Render.WaitFrame()
+	}
+	return nil, nil
+}
+
+func waitFrame(ctx *Context, arg ...interface{}) (interface{}, error) {
+	ctx.Video.Render.WaitFrame()
+	return nil, nil
+}
+
+func frameCount(ctx *Context, arg ...interface{}) (interface{}, error) {
+	return ctx.Video.FrameCount(), nil
+}
+
+func sleep(ctx *Context, arg ...interface{}) (interface{}, error) {
+	ms, ok := floatValue(arg[0])
+	if !ok || ms < 0 {
+		return nil, fmt.Errorf("argument to sleep() should be a number of milliseconds")
+	}
+	ctx.Video.Clock.Sleep(ms / 1000)
+	return nil, nil
+}
+
+func next(ctx *Context, arg ...interface{}) (interface{}, error) {
+	g, ok := arg[0].(*Generator)
+	if !ok {
+		return nil, fmt.Errorf("argument to next() should be a generator")
+	}
+	return g.resume(ctx)
+}
+
+func done(ctx *Context, arg ...interface{}) (interface{}, error) {
+	g, ok := arg[0].(*Generator)
+	if !ok {
+		return nil, fmt.Errorf("argument to done() should be a generator")
+	}
+	return g.finished, nil
+}
+
+func spawn(ctx *Context, arg ...interface{}) (interface{}, error) {
+	g, ok := arg[0].(*Generator)
+	if !ok {
+		return nil, fmt.Errorf("argument to spawn() should be a generator")
+	}
+	for _, coroutine := range ctx.Coroutines {
+		if coroutine == g {
+			return g, nil
+		}
+	}
+	ctx.Coroutines = append(ctx.Coroutines, g)
+	return g, nil
 }
 
 func random(ctx *Context, arg ...interface{}) (interface{}, error) {
@@ -361,281 +725,521 @@ func debug(ctx *Context, arg ...interface{}) (interface{}, error) {
 }
 
 func toAbs(ctx *Context, arg ...interface{}) (interface{}, error) {
-	n, ok := arg[0].(float64)
-	if !ok {
-		return nil, fmt.Errorf("First argument should be a number")
+	switch n := arg[0].(type) {
+	case int:
+		if n < 0 {
+			return -n, nil
+		}
+		return n, nil
+	case float64:
+		return math.Abs(n), nil
 	}
-	return math.Abs(n), nil
+	return nil, fmt.Errorf("First argument should be a number")
 }
 
+// toInt truncates a number towards zero: int(-2.7) is -2
 func toInt(ctx *Context, arg ...interface{}) (interface{}, error) {
-	n, ok := arg[0].(float64)
+	n, ok := floatValue(arg[0])
 	if !ok {
 		return nil, fmt.Errorf("First argument should be a number")
 	}
-	return math.Round(n), nil
+	if math.IsNaN(n) || math.IsInf(n, 0) {
+		return nil, fmt.Errorf("%v can't be converted to an integer", n)
+	}
+	return int(n), nil
 }
 
+// toRound rounds a number to the nearest integer, halves away from zero
 func toRound(ctx *Context, arg ...interface{}) (interface{}, error) {
-	n, ok := arg[0].(float64)
+	n, ok := floatValue(arg[0])
+	if !ok {
+		return nil, fmt.Errorf("First argument should be a number")
+	}
+	if math.IsNaN(n) || math.IsInf(n, 0) {
+		return nil, fmt.Errorf("%v can't be converted to an integer", n)
+	}
+	return int(math.Round(n)), nil
+}
+
+func toFloat(ctx *Context, arg ...interface{}) (interface{}, error) {
+	n, ok := floatValue(arg[0])
 	if !ok {
 		return nil, fmt.Errorf("First argument should be a number")
 	}
-	return float64(int(n)), nil
+	return n, nil
 }
 
 func isKeyDown(ctx *Context, arg ...interface{}) (interface{}, error) {
-	key, ok := arg[0].(float64)
+	key, ok := floatValue(arg[0])
 	if !ok {
 		return nil, fmt.Errorf("First argument should be a number")
 	}
-	// action := ctx.Video.Render.Window.GetKey(glfw.Key(key))
-	// return (action == glfw.Press || action == glfw.Repeat), nil
-	gfx.KeyLock.Lock()
-	b := gfx.KeyDown[glfw.Key(key)]
-	gfx.KeyLock.Unlock()
+	return ctx.Video.Keyboard.IsDown(glfw.Key(key)), nil
+}
 
-	return b, nil
+// keyEvent is a key event as a bscript map: { "key": KeyA, "action": KeyPress, "mods": ModShift }
+func keyEvent(event gfx.KeyEvent) map[string]interface{} {
+	return map[string]interface{}{
+		"key":    int(event.Key),
+		"action": int(event.Action),
+		"mods":   int(event.Mods),
+	}
 }
 
-func assert(ctx *Context, arg ...interface{}) (interface{}, error) {
-	a := arg[0]
-	b := arg[1]
-	msg := "Incorrect value"
+func getKey(ctx *Context, arg ...interface{}) (interface{}, error) {
+	event, ok := ctx.Video.Keyboard.NextKey()
+	if !ok {
+		return nil, nil
+	}
+	return keyEvent(event), nil
+}
+
+func waitKey(ctx *Context, arg ...interface{}) (interface{}, error) {
+	for {
+		// show what was drawn: the keys arrive at the end of a frame
+		if err := ctx.updateVideo("waitKey"); err != nil {
+			return nil, err
+		}
+		if event, ok := ctx.Video.Keyboard.NextKey(); ok {
+			return keyEvent(event), nil
+		}
+		ctx.Video.Render.WaitFrame()
+	}
+}
+
+func clearKeys(ctx *Context, arg ...interface{}) (interface{}, error) {
+	ctx.Video.Keyboard.ClearKeys()
+	return nil, nil
+}
+
+// simulateKey queues a key event as if it was typed, so programs can be tested without a window
+func simulateKey(ctx *Context, arg ...interface{}) (interface{}, error) {
+	key, ok := intValue(arg[0])
+	if !ok {
+		return nil, fmt.Errorf("First argument to simulateKey() should be a key")
+	}
+	action, ok := intValue(arg[1])
+	if !ok || action < int(glfw.Release) || action > int(glfw.Repeat) {
+		return nil, fmt.Errorf("Second argument to simulateKey() should be KeyPress, KeyRelease or KeyRepeat")
+	}
+	mods := 0
 	if len(arg) > 2 {
-		msg = arg[2].(string)
+		if mods, ok = intValue(arg[2]); !ok {
+			return nil, fmt.Errorf("Third argument to simulateKey() should be the mods")
+		}
+	}
+	ctx.Video.Keyboard.KeyEvent(glfw.Key(key), glfw.Action(action), glfw.ModifierKey(mods))
+	return nil, nil
+}
+
+func mouseX(ctx *Context, arg ...interface{}) (interface{}, error) {
+	x, _ := ctx.Video.Mouse.Position()
+	return ctx.Video.MouseX(x), nil
+}
+
+func mouseY(ctx *Context, arg ...interface{}) (interface{}, error) {
+	_, y := ctx.Video.Mouse.Position()
+	return y, nil
+}
+
+func isMouseDown(ctx *Context, arg ...interface{}) (interface{}, error) {
+	button, ok := intValue(arg[0])
+	if !ok {
+		return nil, fmt.Errorf("First argument should be a mouse button")
 	}
+	return ctx.Video.Mouse.IsDown(glfw.MouseButton(button)), nil
+}
 
-	var res bool
+// getMouse returns the next mouse event as a map: { "type": MousePress, "x": 10, "y": 20, "button": MouseLeft, "dx": 0, "dy": 0 }
+func getMouse(ctx *Context, arg ...interface{}) (interface{}, error) {
+	event, ok := ctx.Video.Mouse.NextMouse()
+	if !ok {
+		return nil, nil
+	}
+	return map[string]interface{}{
+		"type":   event.Type,
+		"x":      ctx.Video.MouseX(event.X),
+		"y":      event.Y,
+		"button": int(event.Button),
+		"dx":     event.DX,
+		"dy":     event.DY,
+	}, nil
+}
 
-	// for arrays, compare the values
-	arr, ok := a.(*[]interface{})
-	if ok {
-		// array
-		brr, ok := b.(*[]interface{})
+func clearMouse(ctx *Context, arg ...interface{}) (interface{}, error) {
+	ctx.Video.Mouse.ClearMouse()
+	return nil, nil
+}
+
+// simulateMouseMove moves the mouse to x, y in the video mode's pixels or, given a window size, to x, y in a window of that size
+func simulateMouseMove(ctx *Context, arg ...interface{}) (interface{}, error) {
+	x, ok := floatValue(arg[0])
+	if !ok {
+		return nil, fmt.Errorf("First argument to simulateMouseMove() should be a number")
+	}
+	y, ok := floatValue(arg[1])
+	if !ok {
+		return nil, fmt.Errorf("Second argument to simulateMouseMove() should be a number")
+	}
+	if len(arg) == 3 {
+		return nil, fmt.Errorf("simulateMouseMove() needs both the width and the height of the window")
+	}
+	if len(arg) == 4 {
+		width, ok := intValue(arg[2])
 		if !ok {
-			res = true
-		} else {
-			if len(*arr) == len(*brr) {
-				res = false
-				for i := range *arr {
-					if (*arr)[i] != (*brr)[i] {
-						res = true
-						break
-					}
-				}
-			} else {
-				res = true
+			return nil, fmt.Errorf("Third argument to simulateMouseMove() should be the window's width")
+		}
+		height, ok := intValue(arg[3])
+		if !ok {
+			return nil, fmt.Errorf("Fourth argument to simulateMouseMove() should be the window's height")
+		}
+		ctx.Video.Mouse.Move(ctx.Video.Render.WindowToScreen(x, y, width, height))
+		return nil, nil
+	}
+	if ctx.Video.VideoMode == gfx.GfxMultiColorMode {
+		x *= 2
+	}
+	ctx.Video.Mouse.Move(int(x), int(y))
+	return nil, nil
+}
+
+func simulateMouseButton(ctx *Context, arg ...interface{}) (interface{}, error) {
+	button, ok := intValue(arg[0])
+	if !ok {
+		return nil, fmt.Errorf("First argument to simulateMouseButton() should be a mouse button")
+	}
+	pressed, ok := arg[1].(bool)
+	if !ok {
+		return nil, fmt.Errorf("Second argument to simulateMouseButton() should be true (pressed) or false (released)")
+	}
+	ctx.Video.Mouse.Button(glfw.MouseButton(button), pressed)
+	return nil, nil
+}
+
+func simulateMouseWheel(ctx *Context, arg ...interface{}) (interface{}, error) {
+	dx, ok := floatValue(arg[0])
+	if !ok {
+		return nil, fmt.Errorf("First argument to simulateMouseWheel() should be a number")
+	}
+	dy, ok := floatValue(arg[1])
+	if !ok {
+		return nil, fmt.Errorf("Second argument to simulateMouseWheel() should be a number")
+	}
+	ctx.Video.Mouse.Wheel(dx, dy)
+	return nil, nil
+}
+
+// equals compares values deeply. Arrays, maps and structs are equal if their elements are, and an integer equals a float with the same value.
+func equals(a, b interface{}) bool {
+	if isNumber(a) && isNumber(b) {
+		aNumber, _ := floatValue(a)
+		bNumber, _ := floatValue(b)
+		return aNumber == bNumber
+	}
+	switch a := a.(type) {
+	case *[]interface{}:
+		b, ok := b.(*[]interface{})
+		if !ok || len(*a) != len(*b) {
+			return false
+		}
+		for i := range *a {
+			if !equals((*a)[i], (*b)[i]) {
+				return false
 			}
 		}
-	} else {
-		// map
-		amap, ok := a.(map[string]interface{})
-		if ok {
-			bmap, ok := b.(map[string]interface{})
-			if !ok {
-				res = true
-			} else {
-				if len(amap) == len(bmap) {
-					res = false
-					for k := range amap {
-						if amap[k] != bmap[k] {
-							res = true
-							break
-						}
-					}
-				} else {
-					res = true
-				}
+		return true
+	case map[string]interface{}:
+		b, ok := b.(map[string]interface{})
+		if !ok || len(a) != len(b) {
+			return false
+		}
+		for k, v := range a {
+			bv, ok := b[k]
+			if !ok || !equals(v, bv) {
+				return false
+			}
+		}
+		return true
+	case *StructValue:
+		b, ok := b.(*StructValue)
+		if !ok || a.Type != b.Type {
+			return false
+		}
+		for i := range a.Values {
+			if !equals(a.Values[i], b.Values[i]) {
+				return false
 			}
-		} else {
-			// default is to compare equality
-			res = a != b
 		}
+		return true
 	}
+	return a == b
+}
 
-	if res {
-		debug(ctx, fmt.Sprintf("Assertion failure: %s: %v != %v", msg, a, b))
-		return nil, fmt.Errorf("%s Assertion failure: %s: %v != %v", ctx.Pos, msg, a, b)
+func assert(ctx *Context, arg ...interface{}) (interface{}, error) {
+	a := arg[0]
+	b := arg[1]
+	msg := "Incorrect value"
+	if len(arg) > 2 {
+		msg = arg[2].(string)
+	}
+
+	if !equals(a, b) {
+		debug(ctx, fmt.Sprintf("Assertion failure: %s: %s != %s", msg, EvalString(a), EvalString(b)))
+		return nil, fmt.Errorf("%s Assertion failure: %s: %s != %s", ctx.Pos, msg, EvalString(a), EvalString(b))
 	}
 	return nil, nil
 }
 
 func Builtins() map[string]Builtin {
 	return map[string]Builtin{
-		"print":         print,
-		"input":         input,
-		"len":           length,
-		"keys":          keys,
-		"substr":        substr,
-		"replace":       replace,
-		"debug":         debug,
-		"assert":        assert,
-		"setVideoMode":  setVideoMode,
-		"setPixel":      setPixel,
-		"random":        random,
-		"updateVideo":   updateVideo,
-		"clearVideo":    clearVideo,
-		"drawLine":      drawLine,
-		"drawCircle":    drawCircle,
-		"fillCircle":    fillCircle,
-		"drawRect":      drawRect,
-		"fillRect":      fillRect,
-		"drawText":      drawText,
-		"drawFont":      drawFont,
-		"scroll":        scroll,
-		"trace":         trace,
-		"getTicks":      getTicks,
-		"isKeyDown":     isKeyDown,
-		"setBackground": setBackground,
-		"int":           toInt,
-		"round":         toRound,
-		"abs":           toAbs,
+		"print":               {print, 1, 1},
+		"input":               {input, 1, 1},
+		"len":                 {length, 1, 1},
+		"keys":                {keys, 1, 1},
+		"substr":              {substr, 2, 3},
+		"replace":             {replace, 3, 3},
+		"debug":               {debug, 1, 1},
+		"assert":              {assert, 2, 3},
+		"setVideoMode":        {setVideoMode, 1, 1},
+		"setPixel":            {setPixel, 3, 3},
+		"random":              {random, 0, 0},
+		"updateVideo":         {updateVideo, 0, 1},
+		"waitFrame":           {waitFrame, 0, 0},
+		"frameCount":          {frameCount, 0, 0},
+		"sleep":               {sleep, 1, 1},
+		"clearVideo":          {clearVideo, 0, 0},
+		"drawLine":            {drawLine, 5, 5},
+		"drawCircle":          {drawCircle, 4, 4},
+		"fillCircle":          {fillCircle, 4, 4},
+		"drawRect":            {drawRect, 5, 5},
+		"fillRect":            {fillRect, 5, 5},
+		"drawText":            {drawText, 5, 5},
+		"drawFont":            {drawFont, 5, 5},
+		"setGlyph":            {setGlyph, 2, 2},
+		"setCellColor":        {setCellColor, 4, 4},
+		"getCell":             {getCell, 2, 2},
+		"getPixel":            {getPixel, 2, 2},
+		"getChar":             {getChar, 2, 2},
+		"getScreen":           {getScreen, 0, 0},
+		"peek":                {peek, 1, 1},
+		"poke":                {poke, 2, 2},
+		"getGlyph":            {getGlyph, 1, 1},
+		"loadCharset":         {loadCharset, 1, 1},
+		"scroll":              {scroll, 2, 2},
+		"trace":               {trace, 1, 1},
+		"getTicks":            {getTicks, 0, 0},
+		"isKeyDown":           {isKeyDown, 1, 1},
+		"getKey":              {getKey, 0, 0},
+		"waitKey":             {waitKey, 0, 0},
+		"clearKeys":           {clearKeys, 0, 0},
+		"simulateKey":         {simulateKey, 2, 3},
+		"mouseX":              {mouseX, 0, 0},
+		"mouseY":              {mouseY, 0, 0},
+		"isMouseDown":         {isMouseDown, 1, 1},
+		"getMouse":            {getMouse, 0, 0},
+		"clearMouse":          {clearMouse, 0, 0},
+		"simulateMouseMove":   {simulateMouseMove, 2, 4},
+		"simulateMouseButton": {simulateMouseButton, 2, 2},
+		"simulateMouseWheel":  {simulateMouseWheel, 2, 2},
+		"setBackground":       {setBackground, 1, 1},
+		"setBorder":           {setBorder, 1, 1},
+		"screenshot":          {screenshot, 1, 1},
+		"getFramePixel":       {getFramePixel, 2, 2},
+		"setScrollOffset":     {setScrollOffset, 2, 2},
+		"int":                 {toInt, 1, 1},
+		"round":               {toRound, 1, 1},
+		"float":               {toFloat, 1, 1},
+		"abs":                 {toAbs, 1, 1},
+		"next":                {next, 1, 1},
+		"done":                {done, 1, 1},
+		"spawn":               {spawn, 1, 1},
+		"onFrame":             {onFrame, 1, 1},
+		"setTimeout":          {setTimeout, 2, 2},
+		"setInterval":         {setInterval, 2, 2},
+		"onKey":               {onKey, 2, 2},
+		"onRaster":            {onRaster, 2, 2},
+		"cancel":              {cancel, 1, 1},
+		"setFrameRate":        {setFrameRate, 1, 1},
+		"setPaletteColor":     {setPaletteColor, 4, 4},
+		"getPaletteColor":     {getPaletteColor, 1, 1},
+		"setPalette":          {setPalette, 1, 1},
+		"resetPalette":        {resetPalette, 0, 0},
+		"cyclePalette":        {cyclePalette, 3, 3},
 	}
 }
 
 func Constants() map[string]interface{} {
 	return map[string]interface{}{
+		// memory map, see docs/memory-map.md
+		"VIDEO_ADDRESS":      gfx.VideoAddress,
+		"TEXT_ADDRESS":       gfx.TextAddress,
+		"COLOR_ADDRESS":      gfx.ColorAddress,
+		"FONT_ADDRESS":       gfx.FontAddress,
+		"PALETTE_ADDRESS":    gfx.PaletteAddress,
+		"BACKGROUND_ADDRESS": gfx.BackgroundAddress,
+		"BORDER_ADDRESS":     gfx.BorderAddress,
+		"SCROLL_ADDRESS":     gfx.ScrollAddress,
+		"SPRITE_ADDRESS":     gfx.SpriteAddress,
+		"FREE_ADDRESS":       gfx.FreeAddress,
+
 		// colors
-		"COLOR_BLACK":       float64(gfx.COLOR_BLACK),
-		"COLOR_WHITE":       float64(gfx.COLOR_WHITE),
-		"COLOR_RED":         float64(gfx.COLOR_RED),
-		"COLOR_TEAL":        float64(gfx.COLOR_TEAL),
-		"COLOR_PURPLE":      float64(gfx.COLOR_PURPLE),
-		"COLOR_GREEN":       float64(gfx.COLOR_GREEN),
-		"COLOR_DARK_BLUE":   float64(gfx.COLOR_DARK_BLUE),
-		"COLOR_YELLOW":      float64(gfx.COLOR_YELLOW),
-		"COLOR_BROWN":       float64(gfx.COLOR_BROWN),
-		"COLOR_DARK_BROWN":  float64(gfx.COLOR_DARK_BROWN),
-		"COLOR_TAN":         float64(gfx.COLOR_TAN),
-		"COLOR_DARK_GRAY":   float64(gfx.COLOR_DARK_GRAY),
-		"COLOR_MID_GRAY":    float64(gfx.COLOR_MID_GRAY),
-		"COLOR_LIGHT_GREEN": float64(gfx.COLOR_LIGHT_GREEN),
-		"COLOR_LIGHT_BLUE":  float64(gfx.COLOR_LIGHT_BLUE),
-		"COLOR_LIGHT_GRAY":  float64(gfx.COLOR_LIGHT_GRAY),
+		"COLOR_BLACK":       int(gfx.COLOR_BLACK),
+		"COLOR_WHITE":       int(gfx.COLOR_WHITE),
+		"COLOR_RED":         int(gfx.COLOR_RED),
+		"COLOR_TEAL":        int(gfx.COLOR_TEAL),
+		"COLOR_PURPLE":      int(gfx.COLOR_PURPLE),
+		"COLOR_GREEN":       int(gfx.COLOR_GREEN),
+		"COLOR_DARK_BLUE":   int(gfx.COLOR_DARK_BLUE),
+		"COLOR_YELLOW":      int(gfx.COLOR_YELLOW),
+		"COLOR_BROWN":       int(gfx.COLOR_BROWN),
+		"COLOR_DARK_BROWN":  int(gfx.COLOR_DARK_BROWN),
+		"COLOR_TAN":         int(gfx.COLOR_TAN),
+		"COLOR_DARK_GRAY":   int(gfx.COLOR_DARK_GRAY),
+		"COLOR_MID_GRAY":    int(gfx.COLOR_MID_GRAY),
+		"COLOR_LIGHT_GREEN": int(gfx.COLOR_LIGHT_GREEN),
+		"COLOR_LIGHT_BLUE":  int(gfx.COLOR_LIGHT_BLUE),
+		"COLOR_LIGHT_GRAY":  int(gfx.COLOR_LIGHT_GRAY),
 
 		// keyboard keys
-		"KeyUnknown":      float64(glfw.KeyUnknown),
-		"KeySpace":        float64(glfw.KeySpace),
-		"KeyApostrophe":   float64(glfw.KeyApostrophe),
-		"KeyComma":        float64(glfw.KeyComma),
-		"KeyMinus":        float64(glfw.KeyMinus),
-		"KeyPeriod":       float64(glfw.KeyPeriod),
-		"KeySlash":        float64(glfw.KeySlash),
-		"Key0":            float64(glfw.Key0),
-		"Key1":            float64(glfw.Key1),
-		"Key2":            float64(glfw.Key2),
-		"Key3":            float64(glfw.Key3),
-		"Key4":            float64(glfw.Key4),
-		"Key5":            float64(glfw.Key5),
-		"Key6":            float64(glfw.Key6),
-		"Key7":            float64(glfw.Key7),
-		"Key8":            float64(glfw.Key8),
-		"Key9":            float64(glfw.Key9),
-		"KeySemicolon":    float64(glfw.KeySemicolon),
-		"KeyEqual":        float64(glfw.KeyEqual),
-		"KeyA":            float64(glfw.KeyA),
-		"KeyB":            float64(glfw.KeyB),
-		"KeyC":            float64(glfw.KeyC),
-		"KeyD":            float64(glfw.KeyD),
-		"KeyE":            float64(glfw.KeyE),
-		"KeyF":            float64(glfw.KeyF),
-		"KeyG":            float64(glfw.KeyG),
-		"KeyH":            float64(glfw.KeyH),
-		"KeyI":            float64(glfw.KeyI),
-		"KeyJ":            float64(glfw.KeyJ),
-		"KeyK":            float64(glfw.KeyK),
-		"KeyL":            float64(glfw.KeyL),
-		"KeyM":            float64(glfw.KeyM),
-		"KeyN":            float64(glfw.KeyN),
-		"KeyO":            float64(glfw.KeyO),
-		"KeyP":            float64(glfw.KeyP),
-		"KeyQ":            float64(glfw.KeyQ),
-		"KeyR":            float64(glfw.KeyR),
-		"KeyS":            float64(glfw.KeyS),
-		"KeyT":            float64(glfw.KeyT),
-		"KeyU":            float64(glfw.KeyU),
-		"KeyV":            float64(glfw.KeyV),
-		"KeyW":            float64(glfw.KeyW),
-		"KeyX":            float64(glfw.KeyX),
-		"KeyY":            float64(glfw.KeyY),
-		"KeyZ":            float64(glfw.KeyZ),
-		"KeyLeftBracket":  float64(glfw.KeyLeftBracket),
-		"KeyBackslash":    float64(glfw.KeyBackslash),
-		"KeyRightBracket": float64(glfw.KeyRightBracket),
-		"KeyGraveAccent":  float64(glfw.KeyGraveAccent),
-		"KeyWorld1":       float64(glfw.KeyWorld1),
-		"KeyWorld2":       float64(glfw.KeyWorld2),
-		"KeyEscape":       float64(glfw.KeyEscape),
-		"KeyEnter":        float64(glfw.KeyEnter),
-		"KeyTab":          float64(glfw.KeyTab),
-		"KeyBackspace":    float64(glfw.KeyBackspace),
-		"KeyInsert":       float64(glfw.KeyInsert),
-		"KeyDelete":       float64(glfw.KeyDelete),
-		"KeyRight":        float64(glfw.KeyRight),
-		"KeyLeft":         float64(glfw.KeyLeft),
-		"KeyDown":         float64(glfw.KeyDown),
-		"KeyUp":           float64(glfw.KeyUp),
-		"KeyPageUp":       float64(glfw.KeyPageUp),
-		"KeyPageDown":     float64(glfw.KeyPageDown),
-		"KeyHome":         float64(glfw.KeyHome),
-		"KeyEnd":          float64(glfw.KeyEnd),
-		"KeyCapsLock":     float64(glfw.KeyCapsLock),
-		"KeyScrollLock":   float64(glfw.KeyScrollLock),
-		"KeyNumLock":      float64(glfw.KeyNumLock),
-		"KeyPrintScreen":  float64(glfw.KeyPrintScreen),
-		"KeyPause":        float64(glfw.KeyPause),
-		"KeyF1":           float64(glfw.KeyF1),
-		"KeyF2":           float64(glfw.KeyF2),
-		"KeyF3":           float64(glfw.KeyF3),
-		"KeyF4":           float64(glfw.KeyF4),
-		"KeyF5":           float64(glfw.KeyF5),
-		"KeyF6":           float64(glfw.KeyF6),
-		"KeyF7":           float64(glfw.KeyF7),
-		"KeyF8":           float64(glfw.KeyF8),
-		"KeyF9":           float64(glfw.KeyF9),
-		"KeyF10":          float64(glfw.KeyF10),
-		"KeyF11":          float64(glfw.KeyF11),
-		"KeyF12":          float64(glfw.KeyF12),
-		"KeyF13":          float64(glfw.KeyF13),
-		"KeyF14":          float64(glfw.KeyF14),
-		"KeyF15":          float64(glfw.KeyF15),
-		"KeyF16":          float64(glfw.KeyF16),
-		"KeyF17":          float64(glfw.KeyF17),
-		"KeyF18":          float64(glfw.KeyF18),
-		"KeyF19":          float64(glfw.KeyF19),
-		"KeyF20":          float64(glfw.KeyF20),
-		"KeyF21":          float64(glfw.KeyF21),
-		"KeyF22":          float64(glfw.KeyF22),
-		"KeyF23":          float64(glfw.KeyF23),
-		"KeyF24":          float64(glfw.KeyF24),
-		"KeyF25":          float64(glfw.KeyF25),
-		"KeyKP0":          float64(glfw.KeyKP0),
-		"KeyKP1":          float64(glfw.KeyKP1),
-		"KeyKP2":          float64(glfw.KeyKP2),
-		"KeyKP3":          float64(glfw.KeyKP3),
-		"KeyKP4":          float64(glfw.KeyKP4),
-		"KeyKP5":          float64(glfw.KeyKP5),
-		"KeyKP6":          float64(glfw.KeyKP6),
-		"KeyKP7":          float64(glfw.KeyKP7),
-		"KeyKP8":          float64(glfw.KeyKP8),
-		"KeyKP9":          float64(glfw.KeyKP9),
-		"KeyKPDecimal":    float64(glfw.KeyKPDecimal),
-		"KeyKPDivide":     float64(glfw.KeyKPDivide),
-		"KeyKPMultiply":   float64(glfw.KeyKPMultiply),
-		"KeyKPSubtract":   float64(glfw.KeyKPSubtract),
-		"KeyKPAdd":        float64(glfw.KeyKPAdd),
-		"KeyKPEnter":      float64(glfw.KeyKPEnter),
-		"KeyKPEqual":      float64(glfw.KeyKPEqual),
-		"KeyLeftShift":    float64(glfw.KeyLeftShift),
-		"KeyLeftControl":  float64(glfw.KeyLeftControl),
-		"KeyLeftAlt":      float64(glfw.KeyLeftAlt),
-		"KeyLeftSuper":    float64(glfw.KeyLeftSuper),
-		"KeyRightShift":   float64(glfw.KeyRightShift),
-		"KeyRightControl": float64(glfw.KeyRightControl),
-		"KeyRightAlt":     float64(glfw.KeyRightAlt),
-		"KeyRightSuper":   float64(glfw.KeyRightSuper),
-		"KeyMenu":         float64(glfw.KeyMenu),
-		"KeyLast":         float64(glfw.KeyLast),
+		"KeyUnknown":      int(glfw.KeyUnknown),
+		"KeySpace":        int(glfw.KeySpace),
+		"KeyApostrophe":   int(glfw.KeyApostrophe),
+		"KeyComma":        int(glfw.KeyComma),
+		"KeyMinus":        int(glfw.KeyMinus),
+		"KeyPeriod":       int(glfw.KeyPeriod),
+		"KeySlash":        int(glfw.KeySlash),
+		"Key0":            int(glfw.Key0),
+		"Key1":            int(glfw.Key1),
+		"Key2":            int(glfw.Key2),
+		"Key3":            int(glfw.Key3),
+		"Key4":            int(glfw.Key4),
+		"Key5":            int(glfw.Key5),
+		"Key6":            int(glfw.Key6),
+		"Key7":            int(glfw.Key7),
+		"Key8":            int(glfw.Key8),
+		"Key9":            int(glfw.Key9),
+		"KeySemicolon":    int(glfw.KeySemicolon),
+		"KeyEqual":        int(glfw.KeyEqual),
+		"KeyA":            int(glfw.KeyA),
+		"KeyB":            int(glfw.KeyB),
+		"KeyC":            int(glfw.KeyC),
+		"KeyD":            int(glfw.KeyD),
+		"KeyE":            int(glfw.KeyE),
+		"KeyF":            int(glfw.KeyF),
+		"KeyG":            int(glfw.KeyG),
+		"KeyH":            int(glfw.KeyH),
+		"KeyI":            int(glfw.KeyI),
+		"KeyJ":            int(glfw.KeyJ),
+		"KeyK":            int(glfw.KeyK),
+		"KeyL":            int(glfw.KeyL),
+		"KeyM":            int(glfw.KeyM),
+		"KeyN":            int(glfw.KeyN),
+		"KeyO":            int(glfw.KeyO),
+		"KeyP":            int(glfw.KeyP),
+		"KeyQ":            int(glfw.KeyQ),
+		"KeyR":            int(glfw.KeyR),
+		"KeyS":            int(glfw.KeyS),
+		"KeyT":            int(glfw.KeyT),
+		"KeyU":            int(glfw.KeyU),
+		"KeyV":            int(glfw.KeyV),
+		"KeyW":            int(glfw.KeyW),
+		"KeyX":            int(glfw.KeyX),
+		"KeyY":            int(glfw.KeyY),
+		"KeyZ":            int(glfw.KeyZ),
+		"KeyLeftBracket":  int(glfw.KeyLeftBracket),
+		"KeyBackslash":    int(glfw.KeyBackslash),
+		"KeyRightBracket": int(glfw.KeyRightBracket),
+		"KeyGraveAccent":  int(glfw.KeyGraveAccent),
+		"KeyWorld1":       int(glfw.KeyWorld1),
+		"KeyWorld2":       int(glfw.KeyWorld2),
+		"KeyEscape":       int(glfw.KeyEscape),
+		"KeyEnter":        int(glfw.KeyEnter),
+		"KeyTab":          int(glfw.KeyTab),
+		"KeyBackspace":    int(glfw.KeyBackspace),
+		"KeyInsert":       int(glfw.KeyInsert),
+		"KeyDelete":       int(glfw.KeyDelete),
+		"KeyRight":        int(glfw.KeyRight),
+		"KeyLeft":         int(glfw.KeyLeft),
+		"KeyDown":         int(glfw.KeyDown),
+		"KeyUp":           int(glfw.KeyUp),
+		"KeyPageUp":       int(glfw.KeyPageUp),
+		"KeyPageDown":     int(glfw.KeyPageDown),
+		"KeyHome":         int(glfw.KeyHome),
+		"KeyEnd":          int(glfw.KeyEnd),
+		"KeyCapsLock":     int(glfw.KeyCapsLock),
+		"KeyScrollLock":   int(glfw.KeyScrollLock),
+		"KeyNumLock":      int(glfw.KeyNumLock),
+		"KeyPrintScreen":  int(glfw.KeyPrintScreen),
+		"KeyPause":        int(glfw.KeyPause),
+		"KeyF1":           int(glfw.KeyF1),
+		"KeyF2":           int(glfw.KeyF2),
+		"KeyF3":           int(glfw.KeyF3),
+		"KeyF4":           int(glfw.KeyF4),
+		"KeyF5":           int(glfw.KeyF5),
+		"KeyF6":           int(glfw.KeyF6),
+		"KeyF7":           int(glfw.KeyF7),
+		"KeyF8":           int(glfw.KeyF8),
+		"KeyF9":           int(glfw.KeyF9),
+		"KeyF10":          int(glfw.KeyF10),
+		"KeyF11":          int(glfw.KeyF11),
+		"KeyF12":          int(glfw.KeyF12),
+		"KeyF13":          int(glfw.KeyF13),
+		"KeyF14":          int(glfw.KeyF14),
+		"KeyF15":          int(glfw.KeyF15),
+		"KeyF16":          int(glfw.KeyF16),
+		"KeyF17":          int(glfw.KeyF17),
+		"KeyF18":          int(glfw.KeyF18),
+		"KeyF19":          int(glfw.KeyF19),
+		"KeyF20":          int(glfw.KeyF20),
+		"KeyF21":          int(glfw.KeyF21),
+		"KeyF22":          int(glfw.KeyF22),
+		"KeyF23":          int(glfw.KeyF23),
+		"KeyF24":          int(glfw.KeyF24),
+		"KeyF25":          int(glfw.KeyF25),
+		"KeyKP0":          int(glfw.KeyKP0),
+		"KeyKP1":          int(glfw.KeyKP1),
+		"KeyKP2":          int(glfw.KeyKP2),
+		"KeyKP3":          int(glfw.KeyKP3),
+		"KeyKP4":          int(glfw.KeyKP4),
+		"KeyKP5":          int(glfw.KeyKP5),
+		"KeyKP6":          int(glfw.KeyKP6),
+		"KeyKP7":          int(glfw.KeyKP7),
+		"KeyKP8":          int(glfw.KeyKP8),
+		"KeyKP9":          int(glfw.KeyKP9),
+		"KeyKPDecimal":    int(glfw.KeyKPDecimal),
+		"KeyKPDivide":     int(glfw.KeyKPDivide),
+		"KeyKPMultiply":   int(glfw.KeyKPMultiply),
+		"KeyKPSubtract":   int(glfw.KeyKPSubtract),
+		"KeyKPAdd":        int(glfw.KeyKPAdd),
+		"KeyKPEnter":      int(glfw.KeyKPEnter),
+		"KeyKPEqual":      int(glfw.KeyKPEqual),
+		"KeyLeftShift":    int(glfw.KeyLeftShift),
+		"KeyLeftControl":  int(glfw.KeyLeftControl),
+		"KeyLeftAlt":      int(glfw.KeyLeftAlt),
+		"KeyLeftSuper":    int(glfw.KeyLeftSuper),
+		"KeyRightShift":   int(glfw.KeyRightShift),
+		"KeyRightControl": int(glfw.KeyRightControl),
+		"KeyRightAlt":     int(glfw.KeyRightAlt),
+		"KeyRightSuper":   int(glfw.KeyRightSuper),
+		"KeyMenu":         int(glfw.KeyMenu),
+		"KeyLast":         int(glfw.KeyLast),
+
+		// key event actions and modifiers, see getKey()
+		"KeyPress":   int(glfw.Press),
+		"KeyRelease": int(glfw.Release),
+		"KeyRepeat":  int(glfw.Repeat),
+		"ModShift":   int(glfw.ModShift),
+		"ModControl": int(glfw.ModControl),
+		"ModAlt":     int(glfw.ModAlt),
+		"ModSuper":   int(glfw.ModSuper),
+
+		// mouse buttons and events, see getMouse()
+		"MouseLeft":    int(glfw.MouseButtonLeft),
+		"MouseRight":   int(glfw.MouseButtonRight),
+		"MouseMiddle":  int(glfw.MouseButtonMiddle),
+		"MouseMove":    gfx.MouseMove,
+		"MousePress":   gfx.MousePress,
+		"MouseRelease": gfx.MouseRelease,
+		"MouseWheel":   gfx.MouseWheel,
 	}
 }
@@ -0,0 +1,117 @@
+package bscript
+
+import (
+	"io"
+	"reflect"
+	"strings"
+
+	"github.com/alecthomas/participle/lexer"
+)
+
+// Comments are trivia for the parser: they can go anywhere whitespace can, so the grammar never mentions them.
+// After parsing they are attached to the AST, so tools (like a formatter) can still find them:
+// a comment belongs to the node that starts right after it, and every comment is also in Program.Comments.
+
+// Remark is a # line comment or a #[ block comment ]# from the source
+type Remark struct {
+	Pos lexer.Position
+
+	Comment string
+}
+
+var (
+	remarksType       = reflect.TypeOf([]*Remark{})
+	stringLiteralType = reflect.TypeOf(StringLiteral{})
+)
+
+// attachComments lexes the source again to find the comments and attaches them to the program's nodes
+func (program *Program) attachComments(r io.Reader) error {
+	tokens, err := lexAll(r)
+	if err != nil {
+		return err
+	}
+	comment := benjiLexer.Symbols()["Comment"]
+	whitespace := benjiLexer.Symbols()["Whitespace"]
+
+	nodes := map[int]reflect.Value{}
+	collectCommentNodes(reflect.ValueOf(program.TopLevel), nodes)
+
+	program.Comments = []*Remark{}
+	pending := []*Remark{}
+	for _, token := range tokens {
+		switch token.Type {
+		case comment:
+			remark := &Remark{Pos: token.Pos, Comment: token.Value}
+			program.Comments = append(program.Comments, remark)
+			pending = append(pending, remark)
+		case whitespace:
+		default:
+			if len(pending) == 0 {
+				continue
+			}
+			// comments followed by something that isn't a node (like a closing }) are only kept in Program.Comments
+			if node, ok := nodes[token.Pos.Offset]; ok {
+				field := node.FieldByName("Comments")
+				field.Set(reflect.AppendSlice(field, reflect.ValueOf(pending)))
+			}
+			pending = []*Remark{}
+		}
+	}
+	return nil
+}
+
+// collectCommentNodes finds the nodes below v that can have comments, by their offset in the source.
+// When several nodes start at the same offset, the outermost one gets the comments.
+func collectCommentNodes(v reflect.Value, nodes map[int]reflect.Value) {
+	switch v.Kind() {
+	case reflect.Ptr:
+		if !v.IsNil() {
+			collectCommentNodes(v.Elem(), nodes)
+		}
+	case reflect.Slice:
+		for i := 0; i < v.Len(); i++ {
+			collectCommentNodes(v.Index(i), nodes)
+		}
+	case reflect.Struct:
+		if v.Type() == positionType {
+			return
+		}
+		if v.Type() == stringLiteralType {
+			// the expressions inside a string can't have comments
+			return
+		}
+		if field, ok := v.Type().FieldByName("Comments"); ok && field.Type == remarksType {
+			offset := v.FieldByName("Pos").Interface().(lexer.Position).Offset
+			if _, ok := nodes[offset]; !ok {
+				nodes[offset] = v
+			}
+		}
+		for i := 0; i < v.NumField(); i++ {
+			collectCommentNodes(v.Field(i), nodes)
+		}
+	}
+}
+
+// onlyComments is true if source has nothing but comments and whitespace in it
+func onlyComments(source string) bool {
+	tokens, err := lexAll(strings.NewReader(source))
+	if err != nil {
+		return false
+	}
+	comment := benjiLexer.Symbols()["Comment"]
+	whitespace := benjiLexer.Symbols()["Whitespace"]
+	for _, token := range tokens {
+		if token.Type != lexer.EOF && token.Type != comment && token.Type != whitespace {
+			return false
+		}
+	}
+	return true
+}
+
+func lexAll(r io.Reader) ([]lexer.Token, error) {
+	lex, err := benjiLexer.Lex(r)
+	if err != nil {
+		return nil, err
+	}
+	return lexer.ConsumeAll(lex)
+}
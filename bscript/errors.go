@@ -0,0 +1,101 @@
+package bscript
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/alecthomas/participle/lexer"
+)
+
+// at most this many calls are shown in a stack trace
+const TRACE_LIMIT = 20
+
+// StackFrame is one function call on the runtime stack
+type StackFrame struct {
+	// the called function's name
+	Function string
+	// where the function was called from
+	Pos lexer.Position
+}
+
+// RuntimeError is an error raised while a program is running.
+// It records where it happened and the call stack at that point.
+type RuntimeError struct {
+	Message string
+	Pos     lexer.Position
+	Stack   []StackFrame
+	// the value given to throw, nil for errors raised by the interpreter
+	Value interface{}
+}
+
+func (e *RuntimeError) Error() string {
+	return lexer.FormatError(e.Pos, e.Message)
+}
+
+// Trace returns the error and its call stack (innermost call first) as a readable string
+func (e *RuntimeError) Trace() string {
+	var trace strings.Builder
+	trace.WriteString(fmt.Sprintf("Runtime error: %s\n", e.Message))
+	trace.WriteString(fmt.Sprintf("  at %s\n", e.Pos))
+	for i := len(e.Stack) - 1; i >= 0; i-- {
+		if len(e.Stack)-i > TRACE_LIMIT {
+			trace.WriteString(fmt.Sprintf("  ... %d more calls\n", i+1))
+			break
+		}
+		if e.Stack[i].Pos.Line == 0 {
+			// the call to main() has no source position
+			trace.WriteString(fmt.Sprintf("  in %s()\n", e.Stack[i].Function))
+		} else {
+			trace.WriteString(fmt.Sprintf("  in %s() called from %s\n", e.Stack[i].Function, e.Stack[i].Pos))
+		}
+	}
+	return trace.String()
+}
+
+// toMap converts the error into the map a catch block receives
+func (e *RuntimeError) toMap() map[string]interface{} {
+	stack := make([]interface{}, len(e.Stack))
+	for index, frame := range e.Stack {
+		stack[index] = map[string]interface{}{
+			"function": frame.Function,
+			"position": frame.Pos.String(),
+		}
+	}
+	return map[string]interface{}{
+		"message":  e.Message,
+		"position": e.Pos.String(),
+		"stack":    &stack,
+		"value":    e.Value,
+	}
+}
+
+// snapshot copies the current runtime stack
+func (ctx *Context) snapshot() []StackFrame {
+	stack := make([]StackFrame, len(ctx.RuntimeStack))
+	for index, runtime := range ctx.RuntimeStack {
+		stack[index] = StackFrame{
+			Function: runtime.Function,
+			Pos:      runtime.Pos,
+		}
+	}
+	return stack
+}
+
+// runtimeError wraps err in a RuntimeError, unless it already is one
+func (ctx *Context) runtimeError(err error) error {
+	switch e := err.(type) {
+	case nil:
+		return nil
+	case *RuntimeError:
+		return e
+	case *lexer.Error:
+		return &RuntimeError{Message: e.Message, Pos: e.Pos, Stack: ctx.snapshot()}
+	default:
+		return &RuntimeError{Message: err.Error(), Pos: ctx.Pos, Stack: ctx.snapshot()}
+	}
+}
+
+// recovered converts a recovered go panic into a RuntimeError
+func (ctx *Context) recovered(r interface{}) error {
+	return &RuntimeError{Message: fmt.Sprintf("%v", r), Pos: ctx.Pos, Stack: ctx.snapshot()}
+}
@@ -2,8 +2,10 @@ package bscript
 
 import (
 	"fmt"
-	"math"
+	"io"
 	"os"
+	"reflect"
+	"strconv"
 	"strings"
 
 	"github.com/alecthomas/participle/lexer"
@@ -19,19 +21,27 @@ type Evaluatable interface {
 	Evaluate(ctx *Context) (interface{}, error)
 }
 
-type Builtin func(ctx *Context, args ...interface{}) (interface{}, error)
+// Builtin is a function implemented in go
+type Builtin struct {
+	Fn func(ctx *Context, args ...interface{}) (interface{}, error)
+	// how many arguments the function takes, MaxArgs is VARIADIC if there is no limit
+	MinArgs int
+	MaxArgs int
+}
 
 func (c *Closure) String() string {
-	return fmt.Sprintf("%s(%s)", c.Function, strings.Join(c.Params, ","))
+	return fmt.Sprintf("%s(%s)", c.Function, paramNames(c.Params))
 }
 
 type Closure struct {
 	// function defition: params
-	Params []string
+	Params []*Param
 	// function defition: commands
 	Commands []*Command
 	// The current function's name
 	Function string
+	// true if the function contains yield: calling it returns a Generator
+	Generator bool
 	// variables
 	Vars map[string]interface{}
 	// function definitions
@@ -54,6 +64,8 @@ type Context struct {
 	Builtins map[string]Builtin
 	// top level constants
 	Consts map[string]interface{}
+	// declared structs
+	Structs map[string]*Struct
 	// the global closure
 	Closure *Closure
 	// the runtime stack
@@ -64,15 +76,28 @@ type Context struct {
 	Program *Program
 	// the video card
 	Video *gfx.Gfx
+	// the running generator, nil if it's not in one
+	Generator *Generator
+	// the generators started with spawn(), resumed once per updateVideo()
+	Coroutines []*Generator
+	// true while the coroutines are resumed
+	scheduling bool
+	// the generators that started but haven't finished
+	generators map[*Generator]bool
+	// the handlers run by the event loop after main() returns
+	Events *EventLoop
+	// true while the onRaster() handlers are called, in the middle of a frame
+	drawing bool
 }
 
 func (v *Value) Evaluate(ctx *Context) (interface{}, error) {
 	switch {
 	case v.Number != nil:
+		n := v.Number.Number.Value
 		if v.Number.Sign != nil && *(v.Number.Sign) == "-" {
-			return -v.Number.Number, nil
+			return arithmetic("-", 0, n)
 		}
-		return v.Number.Number, nil
+		return n, nil
 	case v.Boolean != nil:
 		return *v.Boolean == "true", nil
 	case v.Null != nil:
@@ -80,17 +105,16 @@ func (v *Value) Evaluate(ctx *Context) (interface{}, error) {
 	case v.Map != nil:
 		m := make(map[string]interface{})
 		if v.Map.LeftNameValuePair != nil {
-			value, err := v.Map.LeftNameValuePair.Value.Evaluate(ctx)
-			if err != nil {
-				return value, err
-			}
-			m[v.Map.LeftNameValuePair.Name] = value
-			for i := 0; i < len(v.Map.RightNameValuePairs); i++ {
-				value, err := v.Map.RightNameValuePairs[i].Value.Evaluate(ctx)
+			for _, pair := range append([]*NameValuePair{v.Map.LeftNameValuePair}, v.Map.RightNameValuePairs...) {
+				name, err := pair.Name.Evaluate(ctx)
+				if err != nil {
+					return nil, err
+				}
+				value, err := pair.Value.Evaluate(ctx)
 				if err != nil {
 					return value, err
 				}
-				m[v.Map.RightNameValuePairs[i].Name] = value
+				m[name.(string)] = value
 			}
 		}
 		return m, nil
@@ -116,38 +140,11 @@ func (v *Value) Evaluate(ctx *Context) (interface{}, error) {
 		}
 		return &a, nil
 	case v.ArrayElement != nil:
-		currentValue, err := v.ArrayElement.Variable.Evaluate(ctx)
-		if err != nil {
-			return nil, err
-		}
-		for _, arrayIndex := range v.ArrayElement.Indexes {
-			ivalue, err := arrayIndex.Index.Evaluate(ctx)
-			if err != nil {
-				return nil, err
-			}
-
-			a, ok := currentValue.(*[]interface{})
-			if ok {
-				// it's an array
-				index := (int)(ivalue.(float64))
-				if index < 0 || index >= len(*a) {
-					return nil, lexer.Errorf(v.Pos, "Index out of bounds")
-				}
-				currentValue = (*a)[index]
-			} else {
-				// map?
-				m, ok := currentValue.(map[string]interface{})
-				if !ok {
-					return nil, lexer.Errorf(v.Pos, "Array element should refer to array or map")
-				}
-				currentValue = m[ivalue.(string)]
-			}
-		}
-		return currentValue, nil
+		return v.ArrayElement.Evaluate(ctx)
 	case v.AnonFun != nil:
 		return v.AnonFun.Evaluate(ctx)
 	case v.String != nil:
-		return *v.String, nil
+		return v.String.Evaluate(ctx)
 	case v.Variable != nil:
 		return v.Variable.Evaluate(ctx)
 	case v.Subexpression != nil:
@@ -158,6 +155,185 @@ func (v *Value) Evaluate(ctx *Context) (interface{}, error) {
 	panic("unsupported value type" + repr.String(v))
 }
 
+// Evaluate returns the index in [...], or the name after a .
+func (index *ArrayIndex) Evaluate(ctx *Context) (interface{}, error) {
+	if index.Field != nil {
+		return *index.Field, nil
+	}
+	return index.Index.Evaluate(ctx)
+}
+
+func (a *ArrayElement) Evaluate(ctx *Context) (interface{}, error) {
+	container, key, err := a.path(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return getElement(a.Pos, container, a.last(), key)
+}
+
+// last is the final index of the element, the one that is read, assigned or deleted
+func (a *ArrayElement) last() *ArrayIndex {
+	return a.Indexes[len(a.Indexes)-1]
+}
+
+// path evaluates the variable and follows all indexes but the last one.
+// It returns the array, map or struct the last index refers to and the value of the last index.
+func (a *ArrayElement) path(ctx *Context) (interface{}, interface{}, error) {
+	currentValue, err := a.Variable.Evaluate(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+	for _, arrayIndex := range a.Indexes[:len(a.Indexes)-1] {
+		key, err := arrayIndex.Evaluate(ctx)
+		if err != nil {
+			return nil, nil, err
+		}
+		currentValue, err = getElement(a.Pos, currentValue, arrayIndex, key)
+		if err != nil {
+			return nil, nil, err
+		}
+	}
+	key, err := a.last().Evaluate(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+	return currentValue, key, nil
+}
+
+// checkField makes sure that struct fields are accessed with a . and arrays with [...]
+func checkField(pos lexer.Position, container interface{}, index *ArrayIndex) error {
+	switch container.(type) {
+	case *StructValue:
+		if index.Field == nil {
+			return lexer.Errorf(pos, "Struct fields are accessed with a ., like p.x")
+		}
+	case map[string]interface{}:
+		// m.key is the same as m["key"]
+	default:
+		if index.Field != nil {
+			return lexer.Errorf(pos, "Only maps and structs have fields: can't use .%s", *index.Field)
+		}
+	}
+	return nil
+}
+
+func getElement(pos lexer.Position, container interface{}, index *ArrayIndex, key interface{}) (interface{}, error) {
+	if err := checkField(pos, container, index); err != nil {
+		return nil, err
+	}
+	switch c := container.(type) {
+	case *[]interface{}:
+		i, err := toIndex(pos, key)
+		if err != nil {
+			return nil, err
+		}
+		if i < 0 || i >= len(*c) {
+			return nil, lexer.Errorf(pos, "Index out of bounds")
+		}
+		return (*c)[i], nil
+	case map[string]interface{}:
+		k, ok := key.(string)
+		if !ok {
+			return nil, lexer.Errorf(pos, "Map key should be a string")
+		}
+		return c[k], nil
+	case *StructValue:
+		i, err := c.field(pos, key)
+		if err != nil {
+			return nil, err
+		}
+		return c.Values[i], nil
+	}
+	return nil, lexer.Errorf(pos, "Array element should refer to an array, map or struct")
+}
+
+func setElement(pos lexer.Position, container interface{}, index *ArrayIndex, key interface{}, value interface{}) error {
+	if err := checkField(pos, container, index); err != nil {
+		return err
+	}
+	switch c := container.(type) {
+	case *[]interface{}:
+		i, err := toIndex(pos, key)
+		if err != nil {
+			return err
+		}
+		if i < 0 || i > len(*c) {
+			return lexer.Errorf(pos, "Index out of bounds")
+		}
+		if i < len(*c) {
+			(*c)[i] = value
+		} else {
+			*c = append(*c, value)
+		}
+		return nil
+	case map[string]interface{}:
+		k, ok := key.(string)
+		if !ok {
+			return lexer.Errorf(pos, "Map key should be a string")
+		}
+		c[k] = value
+		return nil
+	case *StructValue:
+		i, err := c.field(pos, key)
+		if err != nil {
+			return err
+		}
+		c.Values[i] = value
+		return nil
+	}
+	return lexer.Errorf(pos, "Invalid array element: should be an array, map or struct")
+}
+
+func deleteElement(pos lexer.Position, container interface{}, index *ArrayIndex, key interface{}) error {
+	if err := checkField(pos, container, index); err != nil {
+		return err
+	}
+	switch c := container.(type) {
+	case *[]interface{}:
+		i, err := toIndex(pos, key)
+		if err != nil {
+			return err
+		}
+		if i < 0 || i >= len(*c) {
+			return lexer.Errorf(pos, "Index out of bounds")
+		}
+		*c = append((*c)[:i], (*c)[i+1:]...)
+		return nil
+	case map[string]interface{}:
+		k, ok := key.(string)
+		if !ok {
+			return lexer.Errorf(pos, "Map key should be a string")
+		}
+		delete(c, k)
+		return nil
+	case *StructValue:
+		return lexer.Errorf(pos, "Struct fields can't be deleted")
+	}
+	return lexer.Errorf(pos, "Invalid array element: should be an array, map or struct")
+}
+
+func (s *StringLiteral) Evaluate(ctx *Context) (interface{}, error) {
+	if s.Error != nil {
+		return nil, s.Error
+	}
+	if len(s.Parts) == 1 && s.Parts[0].Expression == nil {
+		return s.Parts[0].Text, nil
+	}
+	var str strings.Builder
+	for _, part := range s.Parts {
+		if part.Expression == nil {
+			str.WriteString(part.Text)
+			continue
+		}
+		value, err := part.Expression.Evaluate(ctx)
+		if err != nil {
+			return nil, err
+		}
+		str.WriteString(EvalString(value))
+	}
+	return str.String(), nil
+}
+
 func (v *Variable) Evaluate(ctx *Context) (interface{}, error) {
 	value, ok := ctx.Consts[v.Variable]
 	if ok {
@@ -176,6 +352,36 @@ func (v *Variable) Evaluate(ctx *Context) (interface{}, error) {
 	return nil, lexer.Errorf(v.Pos, "unknown variable %q", v.Variable)
 }
 
+func (u *Unary) Evaluate(ctx *Context) (interface{}, error) {
+	if u.Factor != nil {
+		return u.Factor.Evaluate(ctx)
+	}
+	value, err := u.Unary.Evaluate(ctx)
+	if err != nil {
+		return nil, err
+	}
+	switch u.Operator {
+	case "!":
+		b, ok := value.(bool)
+		if !ok {
+			return nil, lexer.Errorf(u.Pos, "operand of ! must be a boolean")
+		}
+		return !b, nil
+	case "-":
+		if !isNumber(value) {
+			return nil, lexer.Errorf(u.Pos, "operand of - must be a number")
+		}
+		return arithmetic("-", 0, value)
+	case "~":
+		n, ok := intValue(value)
+		if !ok {
+			return nil, lexer.Errorf(u.Pos, "operand of ~ must be an integer")
+		}
+		return ^n, nil
+	}
+	panic("unreachable")
+}
+
 func (f *Factor) Evaluate(ctx *Context) (interface{}, error) {
 	base, err := f.Base.Evaluate(ctx)
 	if err != nil {
@@ -184,27 +390,27 @@ func (f *Factor) Evaluate(ctx *Context) (interface{}, error) {
 	if f.Exponent == nil {
 		return base, nil
 	}
-	baseNum, exponentNum, err := evaluateFloats(ctx, base, f.Exponent)
+	exponent, err := f.Exponent.Evaluate(ctx)
+	if err != nil {
+		return nil, err
+	}
+	value, err := arithmetic("**", base, exponent)
 	if err != nil {
 		return nil, lexer.Errorf(f.Pos, "invalid factor: %s", err)
 	}
-	return math.Pow(baseNum, exponentNum), nil
+	return value, nil
 }
 
 func (o *OpFactor) Evaluate(ctx *Context, lhs interface{}) (interface{}, error) {
-	lhsNumber, rhsNumber, err := evaluateFloats(ctx, lhs, o.Factor)
+	rhs, err := o.Unary.Evaluate(ctx)
 	if err != nil {
-		return nil, lexer.Errorf(o.Pos, "invalid arguments for %s: %s", o.Operator, err)
+		return nil, err
 	}
-	switch o.Operator {
-	case "*":
-		return lhsNumber * rhsNumber, nil
-	case "/":
-		return lhsNumber / rhsNumber, nil
-	case "%":
-		return float64(int(lhsNumber) % int(rhsNumber)), nil
+	value, err := arithmetic(o.Operator, lhs, rhs)
+	if err != nil {
+		return nil, lexer.Errorf(o.Pos, "invalid arguments for %s: %s", o.Operator, err)
 	}
-	panic("unreachable")
+	return value, nil
 }
 
 func (t *Term) Evaluate(ctx *Context) (interface{}, error) {
@@ -223,27 +429,127 @@ func (t *Term) Evaluate(ctx *Context) (interface{}, error) {
 }
 
 func (o *OpTerm) Evaluate(ctx *Context, lhs interface{}) (interface{}, error) {
-	lhsNumber, rhsNumber, err := evaluateFloats(ctx, lhs, o.Term)
+	rhs, err := o.Term.Evaluate(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return applyOperator(o.Pos, o.Operator, lhs, rhs)
+}
+
+// applyOperator is arithmetic, except that + also concatenates strings
+func applyOperator(pos lexer.Position, operator Operator, lhs, rhs interface{}) (interface{}, error) {
+	if operator == "+" && !(isNumber(lhs) && isNumber(rhs)) {
+		// special handling for string concat
+		return EvalString(lhs) + EvalString(rhs), nil
+	}
+	value, err := arithmetic(operator, lhs, rhs)
+	if err != nil {
+		return nil, lexer.Errorf(pos, "invalid arguments for %s: %s", operator, err)
+	}
+	return value, nil
+}
+
+func (o *OpShift) Evaluate(ctx *Context, lhs interface{}) (interface{}, error) {
+	lhsNumber, rhsNumber, err := evaluateIntegers(ctx, lhs, o.Cmp)
 	if err != nil {
-		if o.Operator == "+" {
-			// special handling for string concat
-			lhsStr, rhsStr, err := evaluateStrings(ctx, lhs, o.Term)
-			if err != nil {
-				return nil, lexer.Errorf(o.Pos, "invalid arguments for %s: %s", o.Operator, err)
-			}
-			return lhsStr + rhsStr, nil
-		}
 		return nil, lexer.Errorf(o.Pos, "invalid arguments for %s: %s", o.Operator, err)
 	}
+	if rhsNumber < 0 {
+		return nil, lexer.Errorf(o.Pos, "negative shift count for %s", o.Operator)
+	}
 	switch o.Operator {
-	case "+":
-		return lhsNumber + rhsNumber, nil
-	case "-":
-		return lhsNumber - rhsNumber, nil
+	case "<<":
+		return lhsNumber << uint(rhsNumber), nil
+	case ">>":
+		return lhsNumber >> uint(rhsNumber), nil
 	}
 	panic("unreachable")
 }
 
+func (s *Shift) Evaluate(ctx *Context) (interface{}, error) {
+	lhs, err := s.Left.Evaluate(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, right := range s.Right {
+		rhs, err := right.Evaluate(ctx, lhs)
+		if err != nil {
+			return nil, err
+		}
+		lhs = rhs
+	}
+	return lhs, nil
+}
+
+func (o *OpBitAnd) Evaluate(ctx *Context, lhs interface{}) (interface{}, error) {
+	lhsNumber, rhsNumber, err := evaluateIntegers(ctx, lhs, o.Shift)
+	if err != nil {
+		return nil, lexer.Errorf(o.Pos, "invalid arguments for %s: %s", o.Operator, err)
+	}
+	return lhsNumber & rhsNumber, nil
+}
+
+func (b *BitAnd) Evaluate(ctx *Context) (interface{}, error) {
+	lhs, err := b.Left.Evaluate(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, right := range b.Right {
+		rhs, err := right.Evaluate(ctx, lhs)
+		if err != nil {
+			return nil, err
+		}
+		lhs = rhs
+	}
+	return lhs, nil
+}
+
+func (o *OpBitXor) Evaluate(ctx *Context, lhs interface{}) (interface{}, error) {
+	lhsNumber, rhsNumber, err := evaluateIntegers(ctx, lhs, o.BitAnd)
+	if err != nil {
+		return nil, lexer.Errorf(o.Pos, "invalid arguments for %s: %s", o.Operator, err)
+	}
+	return lhsNumber ^ rhsNumber, nil
+}
+
+func (b *BitXor) Evaluate(ctx *Context) (interface{}, error) {
+	lhs, err := b.Left.Evaluate(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, right := range b.Right {
+		rhs, err := right.Evaluate(ctx, lhs)
+		if err != nil {
+			return nil, err
+		}
+		lhs = rhs
+	}
+	return lhs, nil
+}
+
+func (o *OpBitOr) Evaluate(ctx *Context, lhs interface{}) (interface{}, error) {
+	lhsNumber, rhsNumber, err := evaluateIntegers(ctx, lhs, o.BitXor)
+	if err != nil {
+		return nil, lexer.Errorf(o.Pos, "invalid arguments for %s: %s", o.Operator, err)
+	}
+	return lhsNumber | rhsNumber, nil
+}
+
+func (b *BitOr) Evaluate(ctx *Context) (interface{}, error) {
+	lhs, err := b.Left.Evaluate(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, right := range b.Right {
+		rhs, err := right.Evaluate(ctx, lhs)
+		if err != nil {
+			return nil, err
+		}
+		lhs = rhs
+	}
+	return lhs, nil
+}
+
 func (c *Cmp) Evaluate(ctx *Context) (interface{}, error) {
 	lhs, err := c.Left.Evaluate(ctx)
 	if err != nil {
@@ -260,36 +566,54 @@ func (c *Cmp) Evaluate(ctx *Context) (interface{}, error) {
 }
 
 func (o *OpCmp) Evaluate(ctx *Context, lhs interface{}) (interface{}, error) {
-	rhs, err := o.Cmp.Evaluate(ctx)
+	rhs, err := o.BitOr.Evaluate(ctx)
 	if err != nil {
 		return nil, err
 	}
-	switch lhs := lhs.(type) {
-	case float64:
-		rhs, ok := rhs.(float64)
-		if !ok {
-			return nil, lexer.Errorf(o.Pos, "rhs of %s must be a number", o.Operator)
-		}
-		switch o.Operator {
+	return compare(o.Pos, o.Operator, lhs, rhs)
+}
+
+// compare applies a comparison operator to two values of the same type
+func compare(pos lexer.Position, operator Operator, lhs, rhs interface{}) (interface{}, error) {
+	if lhs == nil || rhs == nil {
+		// anything can be tested for null
+		switch operator {
 		case "=":
 			return lhs == rhs, nil
 		case "!=":
 			return lhs != rhs, nil
+		default:
+			return nil, lexer.Errorf(pos, "null can't be compared with %s", operator)
+		}
+	}
+	switch lhs := lhs.(type) {
+	case int, float64:
+		if !isNumber(rhs) {
+			return nil, lexer.Errorf(pos, "rhs of %s must be a number", operator)
+		}
+		// an integer equals the float with the same value
+		lhsNumber, _ := floatValue(lhs)
+		rhsNumber, _ := floatValue(rhs)
+		switch operator {
+		case "=":
+			return lhsNumber == rhsNumber, nil
+		case "!=":
+			return lhsNumber != rhsNumber, nil
 		case "<":
-			return lhs < rhs, nil
+			return lhsNumber < rhsNumber, nil
 		case ">":
-			return lhs > rhs, nil
+			return lhsNumber > rhsNumber, nil
 		case "<=":
-			return lhs <= rhs, nil
+			return lhsNumber <= rhsNumber, nil
 		case ">=":
-			return lhs >= rhs, nil
+			return lhsNumber >= rhsNumber, nil
 		}
 	case string:
 		rhs, ok := rhs.(string)
 		if !ok {
-			return nil, lexer.Errorf(o.Pos, "rhs of %s must be a string", o.Operator)
+			return nil, lexer.Errorf(pos, "rhs of %s must be a string", operator)
 		}
-		switch o.Operator {
+		switch operator {
 		case "=":
 			return lhs == rhs, nil
 		case "!=":
@@ -306,49 +630,63 @@ func (o *OpCmp) Evaluate(ctx *Context, lhs interface{}) (interface{}, error) {
 	case bool:
 		rhs, ok := rhs.(bool)
 		if !ok {
-			return nil, lexer.Errorf(o.Pos, "rhs of %s must be a string", o.Operator)
+			return nil, lexer.Errorf(pos, "rhs of %s must be a boolean", operator)
 		}
-		switch o.Operator {
+		switch operator {
 		case "=":
 			return lhs == rhs, nil
 		case "!=":
 			return lhs != rhs, nil
+		default:
+			return nil, lexer.Errorf(pos, "booleans can't be compared with %s", operator)
 		}
 	default:
-		return nil, lexer.Errorf(o.Pos, "lhs of %s must be a number or string", o.Operator)
+		return nil, lexer.Errorf(pos, "lhs of %s must be a number, string or boolean", operator)
 	}
 	panic("unreachable")
 }
 
-func (b *OpBoolTerm) Evaluate(ctx *Context, lhs interface{}) (interface{}, error) {
-	rhs, err := b.Right.Evaluate(ctx)
+func (b *BoolTerm) Evaluate(ctx *Context) (interface{}, error) {
+	lhs, err := b.Left.Evaluate(ctx)
 	if err != nil {
 		return nil, err
 	}
-	switch lhs := lhs.(type) {
-	case bool:
-		rhs, ok := rhs.(bool)
-		if !ok {
-			return nil, lexer.Errorf(b.Pos, "rhs of %s must be a boolean", b.Operator)
-		}
-		switch b.Operator {
-		case "&&":
-			return rhs && lhs, nil
-		case "||":
-			return rhs || lhs, nil
+	for _, right := range b.Right {
+		rhs, err := right.Evaluate(ctx, lhs)
+		if err != nil {
+			return nil, err
 		}
-	default:
-		return nil, lexer.Errorf(b.Pos, "lhs of %s must be a boolean", b.Operator)
+		lhs = rhs
 	}
-	panic("unreachable")
+	return lhs, nil
 }
 
-func (b *BoolTerm) Evaluate(ctx *Context) (interface{}, error) {
-	lhs, err := b.Left.Evaluate(ctx)
+// the rhs of && is only evaluated if the lhs is true
+func (o *OpAndTerm) Evaluate(ctx *Context, lhs interface{}) (interface{}, error) {
+	lhsBool, ok := lhs.(bool)
+	if !ok {
+		return nil, lexer.Errorf(o.Pos, "lhs of %s must be a boolean", o.Operator)
+	}
+	if !lhsBool {
+		return false, nil
+	}
+	rhs, err := o.BoolTerm.Evaluate(ctx)
 	if err != nil {
 		return nil, err
 	}
-	for _, right := range b.Right {
+	rhsBool, ok := rhs.(bool)
+	if !ok {
+		return nil, lexer.Errorf(o.Pos, "rhs of %s must be a boolean", o.Operator)
+	}
+	return rhsBool, nil
+}
+
+func (a *AndTerm) Evaluate(ctx *Context) (interface{}, error) {
+	lhs, err := a.Left.Evaluate(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, right := range a.Right {
 		rhs, err := right.Evaluate(ctx, lhs)
 		if err != nil {
 			return nil, err
@@ -358,12 +696,32 @@ func (b *BoolTerm) Evaluate(ctx *Context) (interface{}, error) {
 	return lhs, nil
 }
 
-func (e *Expression) Evaluate(ctx *Context) (interface{}, error) {
-	lhs, err := e.BoolTerm.Evaluate(ctx)
+// the rhs of || is only evaluated if the lhs is false
+func (o *OpOrTerm) Evaluate(ctx *Context, lhs interface{}) (interface{}, error) {
+	lhsBool, ok := lhs.(bool)
+	if !ok {
+		return nil, lexer.Errorf(o.Pos, "lhs of %s must be a boolean", o.Operator)
+	}
+	if lhsBool {
+		return true, nil
+	}
+	rhs, err := o.AndTerm.Evaluate(ctx)
+	if err != nil {
+		return nil, err
+	}
+	rhsBool, ok := rhs.(bool)
+	if !ok {
+		return nil, lexer.Errorf(o.Pos, "rhs of %s must be a boolean", o.Operator)
+	}
+	return rhsBool, nil
+}
+
+func (o *OrTerm) Evaluate(ctx *Context) (interface{}, error) {
+	lhs, err := o.Left.Evaluate(ctx)
 	if err != nil {
 		return nil, err
 	}
-	for _, right := range e.OpBoolTerm {
+	for _, right := range o.Right {
 		rhs, err := right.Evaluate(ctx, lhs)
 		if err != nil {
 			return nil, err
@@ -373,46 +731,86 @@ func (e *Expression) Evaluate(ctx *Context) (interface{}, error) {
 	return lhs, nil
 }
 
+func (e *Expression) Evaluate(ctx *Context) (interface{}, error) {
+	value, err := e.Condition.Evaluate(ctx)
+	if err != nil {
+		return nil, err
+	}
+	if e.IfTrue == nil {
+		return value, nil
+	}
+	condition, ok := value.(bool)
+	if !ok {
+		return nil, lexer.Errorf(e.Pos, "condition of ?: must be a boolean")
+	}
+	if condition {
+		return e.IfTrue.Evaluate(ctx)
+	}
+	return e.IfFalse.Evaluate(ctx)
+}
+
 func (ctx *Context) debug(message string) {
-	ctx.Builtins["print"](ctx, message)
+	ctx.Builtins["print"].Fn(ctx, message)
 	indent := "  "
-	ctx.Builtins["print"](ctx, "Constants:")
+	ctx.Builtins["print"].Fn(ctx, "Constants:")
 	for k, v := range ctx.Consts {
-		ctx.Builtins["print"](ctx, fmt.Sprintf("  %s=%v", k, v))
+		ctx.Builtins["print"].Fn(ctx, fmt.Sprintf("  %s=%v", k, v))
+	}
+	ctx.Builtins["print"].Fn(ctx, "Structs:")
+	for k, v := range ctx.Structs {
+		ctx.Builtins["print"].Fn(ctx, fmt.Sprintf("  %s{%s}", k, strings.Join(v.Fields, ", ")))
 	}
-	ctx.Builtins["print"](ctx, "Closures:")
+	ctx.Builtins["print"].Fn(ctx, "Closures:")
 	for closure := ctx.Closure; closure != nil; closure = closure.Parent {
-		ctx.Builtins["print"](ctx, "-----------------")
-		ctx.Builtins["print"](ctx, fmt.Sprintf("%sFunction: %s\n", indent, closure.Function))
-		ctx.Builtins["print"](ctx, fmt.Sprintf("%sVars: %v\n", indent, closure.Vars))
-		ctx.Builtins["print"](ctx, fmt.Sprintf("%sDefs: %v\n", indent, closure.Defs))
+		ctx.Builtins["print"].Fn(ctx, "-----------------")
+		ctx.Builtins["print"].Fn(ctx, fmt.Sprintf("%sFunction: %s\n", indent, closure.Function))
+		ctx.Builtins["print"].Fn(ctx, fmt.Sprintf("%sVars: %v\n", indent, closure.Vars))
+		ctx.Builtins["print"].Fn(ctx, fmt.Sprintf("%sDefs: %v\n", indent, closure.Defs))
 		indent = indent + "  "
 	}
-	ctx.Builtins["print"](ctx, "------------------------------------")
-	ctx.Builtins["print"](ctx, "Runtime Call Stack:")
+	ctx.Builtins["print"].Fn(ctx, "------------------------------------")
+	ctx.Builtins["print"].Fn(ctx, "Runtime Call Stack:")
 	indent = "  "
 	for _, runtime := range ctx.RuntimeStack {
-		ctx.Builtins["print"](ctx, fmt.Sprintf("%s%s at %s Vars=%s\n", indent, runtime.Function, runtime.Pos, runtime.Vars))
+		ctx.Builtins["print"].Fn(ctx, fmt.Sprintf("%s%s at %s Vars=%s\n", indent, runtime.Function, runtime.Pos, runtime.Vars))
 		indent = indent + "  "
 	}
-	ctx.Builtins["print"](ctx, "------------------------------------")
-	ctx.Builtins["print"](ctx, fmt.Sprintf("Currently: %s\n", ctx.Pos))
+	ctx.Builtins["print"].Fn(ctx, "------------------------------------")
+	ctx.Builtins["print"].Fn(ctx, fmt.Sprintf("Currently: %s\n", ctx.Pos))
 }
 
-func evalBuiltinCall(ctx *Context, c *Call, builtin Builtin, args []interface{}) (interface{}, error) {
+func evalBuiltinCall(ctx *Context, c *Call, builtin Builtin, args []interface{}) (value interface{}, err error) {
 	// fmt.Println("Calling builtin", c.Name)
 	// a built-in function
 
-	value, err := builtin(ctx, args...)
+	// a go panic in the builtin (eg. a missing or mistyped argument) becomes a runtime error
+	defer func() {
+		if r := recover(); r != nil {
+			value, err = nil, ctx.runtimeError(lexer.Errorf(c.Pos, "%s(): %v", c.Name, r))
+		}
+	}()
+
+	if err := checkArgCount(c.Pos, c.Name, builtin.MinArgs, builtin.MaxArgs, len(args)); err != nil {
+		return nil, err
+	}
+	value, err = builtin.Fn(ctx, args...)
 	if err != nil {
 		return nil, err
 	}
 	return value, nil
 }
 
-func evalFunctionCall(ctx *Context, c *Call, closure *Closure, args []interface{}) (interface{}, error) {
+func evalFunctionCall(ctx *Context, c *Call, closure *Closure, args []interface{}) (value interface{}, err error) {
 	if len(ctx.RuntimeStack) > STACK_LIMIT {
-		panic("Stack limit exceeded")
+		return nil, ctx.runtimeError(lexer.Errorf(c.Pos, "Stack limit exceeded"))
+	}
+	min, max := arity(closure.Params)
+	if err := checkArgCount(c.Pos, c.Name, min, max, len(args)); err != nil {
+		return nil, err
+	}
+	if closure.Generator {
+		// the code runs when next() is called
+		return newGenerator(ctx, c, closure, args)
 	}
 
 	// save local variables (needed when a recursive call modifies the closure's variables)
@@ -426,30 +824,26 @@ func evalFunctionCall(ctx *Context, c *Call, closure *Closure, args []interface{
 		Vars:     saved,
 	})
 	savedClosure := ctx.Closure
-	ctx.Closure = closure
-
-	// create function call param variables
-	if len(closure.Params) != len(args) {
-		return nil, lexer.Errorf(c.Pos, "Not all function params given in call to %s", c.Name)
-	}
-	for index := 0; index < len(closure.Params); index++ {
-		closure.Vars[closure.Params[index]] = args[index]
-	}
 
-	// make the call (evaluate the function's code)
-	value, err := evalBlock(ctx, closure.Commands)
-	if err != nil {
-		return nil, err
-	}
+	// restore local vars and environment (also on error or a go panic, so the caller can carry on)
+	defer func() {
+		if r := recover(); r != nil {
+			// report it here, while the stack still has this call
+			value, err = nil, ctx.recovered(r)
+		}
+		ctx.Closure = savedClosure
+		for k, v := range saved {
+			ctx.Closure.Vars[k] = v
+		}
+		// drop the last frame of the stack
+		ctx.RuntimeStack = ctx.RuntimeStack[:len(ctx.RuntimeStack)-1]
+	}()
 
-	// restore local vars and environment
-	ctx.Closure = savedClosure
-	for k, v := range saved {
-		ctx.Closure.Vars[k] = v
+	// create function call param variables, then make the call (evaluate the function's code)
+	ctx.Closure = closure
+	if err = bindParams(ctx, closure, args); err == nil {
+		value, err = evalBlock(ctx, closure.Commands)
 	}
-	// drop the last frame of the stack
-	ctx.RuntimeStack = ctx.RuntimeStack[:len(ctx.RuntimeStack)-1]
-
 	return value, err
 }
 
@@ -472,20 +866,8 @@ func (closure *Closure) findClosure(name string) (*Closure, bool) {
 	return nil, false
 }
 
-func (callParams *CallParams) evalParams(ctx *Context) ([]interface{}, error) {
-	args := []interface{}{}
-	for _, arg := range callParams.Args {
-		value, err := arg.Evaluate(ctx)
-		if err != nil {
-			return nil, err
-		}
-		args = append(args, value)
-	}
-	return args, nil
-}
-
 func (c *Call) Evaluate(ctx *Context) (interface{}, error) {
-	args, err := c.CallParams[0].evalParams(ctx)
+	args, err := c.CallParams[0].evalArgs(ctx)
 	if err != nil {
 		return nil, err
 	}
@@ -498,25 +880,33 @@ func (c *Call) Evaluate(ctx *Context) (interface{}, error) {
 
 	// call function the first time
 	var result interface{}
-	var fx *Closure
-	for closure := ctx.Closure; closure != nil; closure = closure.Parent {
-		// a defined function
-		fx, ok = closure.findClosure(c.Name)
-		if ok {
-			result, err = evalFunctionCall(ctx, c, fx, args)
-			if err != nil {
-				return nil, err
+	if structType, ok := ctx.Structs[c.Name]; ok {
+		// construct a struct
+		result, err = structType.construct(c.Pos, args)
+		if err != nil {
+			return nil, err
+		}
+	} else {
+		var fx *Closure
+		for closure := ctx.Closure; closure != nil; closure = closure.Parent {
+			// a defined function
+			fx, ok = closure.findClosure(c.Name)
+			if ok {
+				result, err = evalFunctionCall(ctx, c, fx, args)
+				if err != nil {
+					return nil, err
+				}
+				break
 			}
-			break
 		}
-	}
-	if fx == nil {
-		return nil, lexer.Errorf(c.Pos, "Unknown function %s()", c.Name)
+		if fx == nil {
+			return nil, lexer.Errorf(c.Pos, "Unknown function %s()", c.Name)
+		}
 	}
 
 	// subsequent function calls
 	for i := 1; i < len(c.CallParams); i++ {
-		args, err := c.CallParams[i].evalParams(ctx)
+		args, err := c.CallParams[i].evalArgs(ctx)
 		if err != nil {
 			return nil, err
 		}
@@ -533,6 +923,9 @@ func (c *Call) Evaluate(ctx *Context) (interface{}, error) {
 }
 
 func (cmd *Let) Evaluate(ctx *Context) (interface{}, error) {
+	if cmd.Operator != "" {
+		return cmd.evaluateCompound(ctx)
+	}
 	value, err := cmd.Value.Evaluate(ctx)
 
 	if err != nil {
@@ -550,66 +943,68 @@ func (cmd *Let) Evaluate(ctx *Context) (interface{}, error) {
 		// new var
 		ctx.Closure.Vars[*cmd.Variable] = value
 	} else if cmd.ArrayElement != nil {
-		currentValue, err := cmd.ArrayElement.Variable.Evaluate(ctx)
+		container, key, err := cmd.ArrayElement.path(ctx)
 		if err != nil {
 			return nil, err
 		}
+		return nil, setElement(cmd.Pos, container, cmd.ArrayElement.last(), key, value)
+	} else {
+		return nil, lexer.Errorf(cmd.Pos, "Let needs a variable or array element on the LHS.")
+	}
+	return nil, nil
+}
 
-		for arrayIndexIndex, arrayIndex := range cmd.ArrayElement.Indexes {
-			ivalue, err := arrayIndex.Index.Evaluate(ctx)
-			if err != nil {
-				return nil, err
-			}
-
-			lastElement := arrayIndexIndex == len(cmd.ArrayElement.Indexes)-1
-
-			a, ok := currentValue.(*[]interface{})
+// evaluateCompound runs a += b and the other compound assignments.
+// The target is evaluated once: in a[f()] += 1, f() is only called once.
+func (cmd *Let) evaluateCompound(ctx *Context) (interface{}, error) {
+	if cmd.Variable != nil {
+		for c := ctx.Closure; c != nil; c = c.Parent {
+			current, ok := c.Vars[*cmd.Variable]
 			if ok {
-				// it's an array
-				index := (int)(ivalue.(float64))
-				if lastElement {
-					if index < 0 || index > len(*a) {
-						return nil, lexer.Errorf(cmd.Pos, "Index out of bounds")
-					}
-					if index < len(*a) {
-						(*a)[index] = value
-					} else {
-						*a = append(*a, value)
-					}
-				} else {
-					if index < 0 || index >= len(*a) {
-						return nil, lexer.Errorf(cmd.Pos, "Index out of bounds")
-					}
-					currentValue = (*a)[index]
+				rhs, err := cmd.Value.Evaluate(ctx)
+				if err != nil {
+					return nil, err
 				}
-			} else {
-				m, ok := currentValue.(map[string]interface{})
-				if ok {
-					key := ivalue.(string)
-					if lastElement {
-						m[key] = value
-					} else {
-						currentValue = m[key]
-					}
-				} else {
-					return nil, lexer.Errorf(cmd.Pos, "Invalid array element: should be an array or a map")
+				value, err := applyOperator(cmd.Pos, cmd.Operator, current, rhs)
+				if err != nil {
+					return nil, err
 				}
+				c.Vars[*cmd.Variable] = value
+				return nil, nil
 			}
 		}
-	} else {
-		return nil, lexer.Errorf(cmd.Pos, "Let needs a variable or array element on the LHS.")
+		return nil, lexer.Errorf(cmd.Pos, "unknown variable %q", *cmd.Variable)
 	}
-	return nil, nil
+
+	container, key, err := cmd.ArrayElement.path(ctx)
+	if err != nil {
+		return nil, err
+	}
+	current, err := getElement(cmd.Pos, container, cmd.ArrayElement.last(), key)
+	if err != nil {
+		return nil, err
+	}
+	rhs, err := cmd.Value.Evaluate(ctx)
+	if err != nil {
+		return nil, err
+	}
+	value, err := applyOperator(cmd.Pos, cmd.Operator, current, rhs)
+	if err != nil {
+		return nil, err
+	}
+	return nil, setElement(cmd.Pos, container, cmd.ArrayElement.last(), key, value)
 }
 
 // Evaluate a Command.
 // some commands return a value which causes the exection of a block to stop (eg. return, while, if)
 func (cmd *Command) Evaluate(ctx *Context) (interface{}, error) {
 	ctx.Pos = cmd.Pos
+	value, err := cmd.evaluate(ctx)
+	return value, ctx.runtimeError(err)
+}
 
+func (cmd *Command) evaluate(ctx *Context) (interface{}, error) {
 	switch {
-	case cmd.Remark != nil:
-		return nil, nil
 	case cmd.Let != nil:
 		_, err := cmd.Let.Evaluate(ctx)
 		return nil, err
@@ -626,8 +1021,16 @@ func (cmd *Command) Evaluate(ctx *Context) (interface{}, error) {
 		return nil, err
 	case cmd.If != nil:
 		return cmd.If.Evaluate(ctx)
+	case cmd.Switch != nil:
+		return cmd.Switch.Evaluate(ctx)
 	case cmd.While != nil:
 		return cmd.While.Evaluate(ctx)
+	case cmd.Try != nil:
+		return cmd.Try.Evaluate(ctx)
+	case cmd.Throw != nil:
+		return cmd.Throw.Evaluate(ctx)
+	case cmd.Yield != nil:
+		return cmd.Yield.Evaluate(ctx)
 	default:
 		panic("unsupported command " + repr.String(cmd))
 	}
@@ -650,51 +1053,15 @@ func evalBlock(ctx *Context, commands []*Command) (interface{}, error) {
 }
 
 func (cmd *Del) Evaluate(ctx *Context) (interface{}, error) {
-	if cmd.ArrayElement != nil {
-		currentValue, err := cmd.ArrayElement.Variable.Evaluate(ctx)
-		if err != nil {
-			return nil, err
-		}
-
-		for arrayIndexIndex, arrayIndex := range cmd.ArrayElement.Indexes {
-			ivalue, err := arrayIndex.Index.Evaluate(ctx)
-			if err != nil {
-				return nil, err
-			}
-
-			lastElement := arrayIndexIndex == len(cmd.ArrayElement.Indexes)-1
-
-			a, ok := currentValue.(*[]interface{})
-			if ok {
-				// it's an array
-				index := (int)(ivalue.(float64))
-				if index < 0 || index >= len(*a) {
-					return nil, lexer.Errorf(cmd.Pos, "Index out of bounds")
-				}
-				if lastElement {
-					*a = append((*a)[:index], (*a)[index+1:]...)
-				} else {
-					currentValue = (*a)[index]
-				}
-			} else {
-				m, ok := currentValue.(map[string]interface{})
-				if ok {
-					key := ivalue.(string)
-					if lastElement {
-						delete(m, key)
-					} else {
-						currentValue = m[key]
-					}
-				} else {
-					return nil, lexer.Errorf(cmd.Pos, "Invalid array element: should be an array or a map")
-				}
-			}
-		}
-	} else {
+	if cmd.ArrayElement == nil {
 		// in the future, del can take other types (map, maybe struct, etc)
 		return nil, lexer.Errorf(cmd.Pos, "can't delete this type of expression")
 	}
-	return nil, nil
+	container, key, err := cmd.ArrayElement.path(ctx)
+	if err != nil {
+		return nil, err
+	}
+	return nil, deleteElement(cmd.Pos, container, cmd.ArrayElement.last(), key)
 }
 
 func (whilecommand *While) Evaluate(ctx *Context) (interface{}, error) {
@@ -719,6 +1086,7 @@ func (whilecommand *While) Evaluate(ctx *Context) (interface{}, error) {
 }
 
 func (ifcommand *If) Evaluate(ctx *Context) (interface{}, error) {
+	ctx.Pos = ifcommand.Pos
 	value, err := ifcommand.Condition.Evaluate(ctx)
 	if err != nil {
 		return nil, err
@@ -727,21 +1095,105 @@ func (ifcommand *If) Evaluate(ctx *Context) (interface{}, error) {
 	if value == true {
 		return evalBlock(ctx, ifcommand.Commands)
 	}
+	if ifcommand.ElseIf != nil {
+		return ifcommand.ElseIf.Evaluate(ctx)
+	}
 	return evalBlock(ctx, ifcommand.ElseCommands)
 }
 
-func makeClosure(ctx *Context, name string, params []string, commands []*Command) *Closure {
+func (switchcommand *Switch) Evaluate(ctx *Context) (interface{}, error) {
+	value, err := switchcommand.Value.Evaluate(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	for _, c := range switchcommand.Cases {
+		ctx.Pos = c.Pos
+		for _, caseValue := range c.Values {
+			v, err := caseValue.Evaluate(ctx)
+			if err != nil {
+				return nil, err
+			}
+			equal, err := compare(caseValue.Pos, "=", value, v)
+			if err != nil {
+				return nil, err
+			}
+			if equal == true {
+				return evalBlock(ctx, c.Commands)
+			}
+		}
+	}
+	return evalBlock(ctx, switchcommand.DefaultCommands)
+}
+
+func (throw *Throw) Evaluate(ctx *Context) (interface{}, error) {
+	value, err := throw.Value.Evaluate(ctx)
+	if err != nil {
+		return nil, err
+	}
+	message := EvalString(value)
+	if m, ok := value.(map[string]interface{}); ok {
+		// re-throwing a caught exception keeps its message
+		if s, ok := m["message"].(string); ok {
+			message = s
+		}
+	}
+	return nil, &RuntimeError{Message: message, Pos: throw.Pos, Stack: ctx.snapshot(), Value: value}
+}
+
+// evalTryBlock is evalBlock that also turns go panics into runtime errors,
+// so that they can be caught
+func evalTryBlock(ctx *Context, commands []*Command) (value interface{}, err error) {
+	closure := ctx.Closure
+	stackSize := len(ctx.RuntimeStack)
+	defer func() {
+		if r := recover(); r != nil {
+			value, err = nil, ctx.recovered(r)
+			ctx.Closure = closure
+			ctx.RuntimeStack = ctx.RuntimeStack[:stackSize]
+		}
+	}()
+	value, err = evalBlock(ctx, commands)
+	return value, ctx.runtimeError(err)
+}
+
+func (try *Try) Evaluate(ctx *Context) (interface{}, error) {
+	if try.CatchVariable == nil && !try.Finally {
+		return nil, lexer.Errorf(try.Pos, "try needs a catch or a finally block")
+	}
+	value, err := evalTryBlock(ctx, try.Commands)
+	// a generator being stopped can't catch that
+	stopping := ctx.Generator != nil && ctx.Generator.stopping
+	if err != nil && try.CatchVariable != nil && !stopping {
+		ctx.Closure.Vars[*try.CatchVariable] = err.(*RuntimeError).toMap()
+		value, err = evalTryBlock(ctx, try.CatchCommands)
+	}
+	if try.Finally {
+		// finally always runs; its own return or error wins
+		finallyValue, finallyErr := evalBlock(ctx, try.FinallyCommands)
+		if finallyErr != nil || finallyValue != nil {
+			return finallyValue, finallyErr
+		}
+	}
+	return value, err
+}
+
+func makeClosure(ctx *Context, name string, params []*Param, commands []*Command) *Closure {
 	return &Closure{
-		Params:   params,
-		Commands: commands,
-		Vars:     map[string]interface{}{},
-		Defs:     map[string]*Closure{},
-		Function: name,
-		Parent:   ctx.Closure,
+		Params:    params,
+		Commands:  commands,
+		Vars:      map[string]interface{}{},
+		Defs:      map[string]*Closure{},
+		Function:  name,
+		Generator: containsYield(reflect.ValueOf(commands)),
+		Parent:    ctx.Closure,
 	}
 }
 
 func (fun *Fun) Evaluate(ctx *Context) (interface{}, error) {
+	if err := checkParams(fun.Name, fun.Params); err != nil {
+		return nil, err
+	}
 	ctx.Closure.Defs[fun.Name] = makeClosure(ctx, fun.Name, fun.Params, fun.Commands)
 	return nil, nil
 }
@@ -749,11 +1201,16 @@ func (fun *Fun) Evaluate(ctx *Context) (interface{}, error) {
 func (anonFun *AnonFun) Evaluate(ctx *Context) (interface{}, error) {
 	name := fmt.Sprintf("_anon_%d", ANON_COUNT)
 	ANON_COUNT++
-	var params []string
+	var params []*Param
 	if anonFun.SingleParam != nil {
-		params = []string{*anonFun.SingleParam}
+		params = []*Param{&Param{Pos: anonFun.Pos, Name: *anonFun.SingleParam}}
 	} else {
-		params = anonFun.Params
+		for _, param := range anonFun.Params {
+			params = append(params, &Param{Pos: param.Pos, Rest: param.Rest, Name: param.Name})
+		}
+		if err := checkParams(name, params); err != nil {
+			return nil, err
+		}
 	}
 	var commands []*Command
 	if anonFun.SingleCommand != nil {
@@ -775,7 +1232,7 @@ func (anonFun *AnonFun) Evaluate(ctx *Context) (interface{}, error) {
 func CreateContext(program *Program) *Context {
 	global := &Closure{
 		Function: "global",
-		Params:   []string{},
+		Params:   []*Param{},
 		Commands: []*Command{},
 		Vars:     map[string]interface{}{},
 		Defs:     map[string]*Closure{},
@@ -783,12 +1240,14 @@ func CreateContext(program *Program) *Context {
 	}
 	return &Context{
 		Consts:       Constants(),
+		Structs:      map[string]*Struct{},
 		Builtins:     Builtins(),
 		Closure:      global,
 		RuntimeStack: []Runtime{},
 		Pos:          lexer.Position{},
 		Program:      program,
 		Video:        nil,
+		Events:       newEventLoop(),
 	}
 }
 
@@ -800,7 +1259,16 @@ func load(source string, showAst *bool) (*Program, error) {
 	defer r.Close()
 
 	ast := &Program{}
-	Parser.Parse(r, ast)
+	err = Parser.Parse(r, ast)
+	if err != nil {
+		return nil, err
+	}
+	if _, err = r.Seek(0, io.SeekStart); err != nil {
+		return nil, err
+	}
+	if err = ast.attachComments(r); err != nil {
+		return nil, err
+	}
 	if showAst != nil && *showAst {
 		// print the ast
 		repr.Println(ast)
@@ -844,18 +1312,28 @@ func (program *Program) init(ctx *Context) (*Context, error) {
 		return ctx, nil
 	}
 
+	// declare structs, so constants and globals can use them
+	for i := 0; i < len(program.TopLevel); i++ {
+		if program.TopLevel[i].Struct != nil {
+			_, err := program.TopLevel[i].Struct.Evaluate(ctx)
+			if err != nil {
+				return ctx, err
+			}
+		}
+	}
+
 	// define constants and globals
 	for i := 0; i < len(program.TopLevel); i++ {
 		if program.TopLevel[i].Const != nil {
 			value, err := program.TopLevel[i].Const.Value.Evaluate(ctx)
 			if err != nil {
-				return ctx, err
+				return ctx, ctx.runtimeError(err)
 			}
 			ctx.Consts[program.TopLevel[i].Const.Name] = value
 		} else if program.TopLevel[i].Let != nil {
 			_, err := program.TopLevel[i].Let.Evaluate(ctx)
 			if err != nil {
-				return ctx, err
+				return ctx, ctx.runtimeError(err)
 			}
 		}
 	}
@@ -863,6 +1341,9 @@ func (program *Program) init(ctx *Context) (*Context, error) {
 	// define functions
 	for i := 0; i < len(program.TopLevel); i++ {
 		if program.TopLevel[i].Fun != nil {
+			if _, ok := ctx.Structs[program.TopLevel[i].Fun.Name]; ok {
+				return ctx, lexer.Errorf(program.TopLevel[i].Fun.Pos, "function %s has the same name as a struct", program.TopLevel[i].Fun.Name)
+			}
 			_, err := program.TopLevel[i].Fun.Evaluate(ctx)
 			if err != nil {
 				return ctx, err
@@ -878,52 +1359,86 @@ func (program *Program) init(ctx *Context) (*Context, error) {
 	return ctx, nil
 }
 
-func (program *Program) Evaluate(ctx *Context) (interface{}, error) {
+func (program *Program) Evaluate(ctx *Context) (value interface{}, err error) {
+	closure := ctx.Closure
+	stackSize := len(ctx.RuntimeStack)
+	defer func() {
+		// an unexpected go panic is reported like any other runtime error
+		if r := recover(); r != nil {
+			value, err = nil, ctx.recovered(r)
+		}
+		ctx.Closure = closure
+		if err != nil {
+			ctx.RuntimeStack = ctx.RuntimeStack[:stackSize]
+		}
+		// generators left waiting at a yield would never be resumed
+		ctx.stopGenerators()
+	}()
+
+	// handlers left over from an earlier run (e.g. in the repl) are dropped
+	ctx.Events = newEventLoop()
+	if ctx.Video != nil {
+		ctx.watchRaster()
+	}
+
+	// main() and the event handlers are called from a closure of their own: a call restores the caller's
+	// variables afterwards, so calling them from the global closure would undo their changes to globals
+	ctx.Closure = &Closure{
+		Function: "events",
+		Params:   []*Param{},
+		Commands: []*Command{},
+		Vars:     map[string]interface{}{},
+		Defs:     map[string]*Closure{},
+		Parent:   closure,
+		Video:    closure.Video,
+	}
 
 	// Call main()
 	call := &Call{
 		Name: "main",
 		CallParams: []*CallParams{
 			&CallParams{
-				Args: []*Expression{},
+				Args: []*Arg{},
 			},
 		},
 	}
-	return call.Evaluate(ctx)
+	value, err = call.Evaluate(ctx)
+	if err == nil {
+		// main() may have left handlers for the event loop
+		err = ctx.runEvents()
+	}
+	return value, ctx.runtimeError(err)
 }
 
-func evaluateFloats(ctx *Context, lhs interface{}, rhsExpr Evaluatable) (float64, float64, error) {
+func evaluateIntegers(ctx *Context, lhs interface{}, rhsExpr Evaluatable) (int, int, error) {
 	rhs, err := rhsExpr.Evaluate(ctx)
 	if err != nil {
 		return 0, 0, err
 	}
-	lhsNumber, ok := lhs.(float64)
+	lhsNumber, ok := intValue(lhs)
 	if !ok {
-		return 0, 0, fmt.Errorf("lhs must be a number")
+		return 0, 0, fmt.Errorf("lhs must be an integer")
 	}
-	rhsNumber, ok := rhs.(float64)
+	rhsNumber, ok := intValue(rhs)
 	if !ok {
-		return 0, 0, fmt.Errorf("rhs must be a number")
+		return 0, 0, fmt.Errorf("rhs must be an integer")
 	}
 	return lhsNumber, rhsNumber, nil
 }
 
 func EvalString(value interface{}) string {
-	avalue, ok := value.(*[]interface{})
-	if ok {
-		a := make([]string, len(*avalue))
-		for idx, aa := range *avalue {
+	switch v := value.(type) {
+	case *[]interface{}:
+		a := make([]string, len(*v))
+		for idx, aa := range *v {
 			a[idx] = EvalString(aa)
 		}
 		return fmt.Sprintf("%v", a)
+	case int:
+		return strconv.Itoa(v)
+	case float64:
+		// the shortest representation that reads back as the same float
+		return strconv.FormatFloat(v, 'g', -1, 64)
 	}
 	return fmt.Sprintf("%v", value)
 }
-
-func evaluateStrings(ctx *Context, lhs interface{}, rhsExpr Evaluatable) (string, string, error) {
-	rhs, err := rhsExpr.Evaluate(ctx)
-	if err != nil {
-		return "", "", err
-	}
-	return EvalString(lhs), EvalString(rhs), nil
-}
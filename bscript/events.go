@@ -0,0 +1,283 @@
+package bscript
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/alecthomas/participle/lexer"
+	"github.com/go-gl/glfw/v3.2/glfw"
+	"github.com/uzudil/benji4000/gfx"
+)
+
+// The event loop lets a program run without a loop of its own: main() registers handlers with
+// onFrame(), setTimeout(), setInterval() and onKey(), and returns. After that the interpreter runs one
+// frame at a time, at the rate of Render.GetFps(), until no handlers are left.
+//
+// onRaster() handlers are different: they're called while updateVideo() draws the frame, before the line they were
+// registered for, so they can change the video mode, palette or scroll offset for the rest of the frame.
+
+// EventLoop holds the handlers registered by a program
+type EventLoop struct {
+	// the id of the last handler registered
+	lastID int
+	// handlers in the order they were registered
+	handlers []*handler
+	// when the last frame was shown
+	lastFrame time.Time
+}
+
+type handler struct {
+	id      int
+	closure *Closure
+	// where the handler was registered: it's called "from" there
+	pos lexer.Position
+	// true for onFrame() handlers
+	frame bool
+	// the display line of onRaster() handlers
+	raster bool
+	line   int
+	// the key of onKey() handlers, and how many times it was pressed when last checked
+	key     int
+	isKey   bool
+	presses int
+	// when a timer is due and how often it repeats (0 for setTimeout), in seconds
+	timer    bool
+	due      float64
+	interval float64
+	// cancel() was called
+	cancelled bool
+}
+
+func newEventLoop() *EventLoop {
+	return &EventLoop{handlers: []*handler{}}
+}
+
+func (events *EventLoop) add(h *handler) int {
+	events.lastID++
+	h.id = events.lastID
+	events.handlers = append(events.handlers, h)
+	return h.id
+}
+
+func (events *EventLoop) cancel(id int) bool {
+	for index, h := range events.handlers {
+		if h.id == id {
+			h.cancelled = true
+			events.handlers = append(events.handlers[:index:index], events.handlers[index+1:]...)
+			return true
+		}
+	}
+	return false
+}
+
+// paceFrame blocks until it's time to show the next frame, so the program runs at Render.GetFps() at most.
+// It uses the real time, not the machine's clock, which may be a SteppedClock.
+func (ctx *Context) paceFrame() {
+	fps := ctx.Video.Render.GetFps()
+	if fps <= 0 {
+		return
+	}
+	next := ctx.Events.lastFrame.Add(time.Duration(float64(time.Second) / fps))
+	if wait := time.Until(next); wait > 0 {
+		time.Sleep(wait)
+	}
+	ctx.Events.lastFrame = time.Now()
+}
+
+// live is true while there are handlers that need the event loop. onRaster() handlers only change how frames are
+// drawn, so they don't keep it running.
+func (events *EventLoop) live() bool {
+	for _, h := range events.handlers {
+		if !h.raster {
+			return true
+		}
+	}
+	return false
+}
+
+// runEvents is the event loop: it runs after main() returns, as long as there are handlers or coroutines
+func (ctx *Context) runEvents() error {
+	for ctx.Events.live() || len(ctx.Coroutines) > 0 {
+		if err := ctx.runFrame(); err != nil {
+			return err
+		}
+		if _, err := ctx.Builtins["updateVideo"].Fn(ctx); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// runFrame calls the handlers for one frame: due timers first, then pressed keys, then onFrame() handlers
+func (ctx *Context) runFrame() error {
+	now := ctx.Video.GetTicks()
+	// handlers registered during the frame run from the next one
+	handlers := append([]*handler{}, ctx.Events.handlers...)
+	for _, pass := range []func(h *handler) bool{
+		func(h *handler) bool {
+			if !h.timer || now < h.due {
+				return false
+			}
+			if h.interval > 0 {
+				h.due += h.interval
+				if h.due < now {
+					// don't try to catch up on missed calls
+					h.due = now + h.interval
+				}
+			} else {
+				ctx.Events.cancel(h.id)
+			}
+			return true
+		},
+		func(h *handler) bool {
+			if !h.isKey {
+				return false
+			}
+			presses := ctx.Video.Keyboard.Presses(glfw.Key(h.key))
+			pressed := presses != h.presses
+			h.presses = presses
+			return pressed
+		},
+		func(h *handler) bool {
+			return h.frame
+		},
+	} {
+		for _, h := range handlers {
+			if h.cancelled || !pass(h) {
+				continue
+			}
+			call := &Call{Pos: h.pos, Name: h.closure.Function}
+			if _, err := evalFunctionCall(ctx, call, h.closure, []interface{}{}); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// runRaster calls the onRaster() handlers for the display line y. It's called by UpdateVideo() before it draws the line.
+func (ctx *Context) runRaster(y int) error {
+	ctx.drawing = true
+	defer func() { ctx.drawing = false }()
+	for _, h := range ctx.Events.handlers {
+		if h.cancelled || !h.raster || h.line != y {
+			continue
+		}
+		call := &Call{Pos: h.pos, Name: h.closure.Function}
+		if _, err := evalFunctionCall(ctx, call, h.closure, []interface{}{}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// watchRaster makes UpdateVideo() call runRaster() while there are onRaster() handlers, and stops it when there are none.
+func (ctx *Context) watchRaster() {
+	ctx.Video.OnScanline = nil
+	for _, h := range ctx.Events.handlers {
+		if h.raster {
+			ctx.Video.OnScanline = ctx.runRaster
+			return
+		}
+	}
+}
+
+// updateVideo shows the next frame for the builtin name. A frame can't be shown while one is being drawn.
+func (ctx *Context) updateVideo(name string) error {
+	if ctx.drawing {
+		return fmt.Errorf("%s() can't be called from an onRaster() handler", name)
+	}
+	return ctx.Video.UpdateVideo()
+}
+
+func handlerClosure(name string, arg interface{}) (*Closure, error) {
+	closure, ok := arg.(*Closure)
+	if !ok {
+		return nil, fmt.Errorf("argument to %s() should be a function", name)
+	}
+	return closure, nil
+}
+
+func timerHandler(ctx *Context, name string, arg []interface{}, repeat bool) (interface{}, error) {
+	closure, err := handlerClosure(name, arg[0])
+	if err != nil {
+		return nil, err
+	}
+	ms, ok := floatValue(arg[1])
+	if !ok || ms < 0 {
+		return nil, fmt.Errorf("Second argument to %s() should be a number of milliseconds", name)
+	}
+	h := &handler{closure: closure, pos: ctx.Pos, timer: true, due: ctx.Video.GetTicks() + ms/1000}
+	if repeat {
+		// an interval of 0 would never let the frame end
+		h.interval = ms / 1000
+		if h.interval == 0 {
+			h.interval = 1 / ctx.Video.Render.GetFps()
+		}
+	}
+	return ctx.Events.add(h), nil
+}
+
+func onFrame(ctx *Context, arg ...interface{}) (interface{}, error) {
+	closure, err := handlerClosure("onFrame", arg[0])
+	if err != nil {
+		return nil, err
+	}
+	return ctx.Events.add(&handler{closure: closure, pos: ctx.Pos, frame: true}), nil
+}
+
+func setTimeout(ctx *Context, arg ...interface{}) (interface{}, error) {
+	return timerHandler(ctx, "setTimeout", arg, false)
+}
+
+func setInterval(ctx *Context, arg ...interface{}) (interface{}, error) {
+	return timerHandler(ctx, "setInterval", arg, true)
+}
+
+func onKey(ctx *Context, arg ...interface{}) (interface{}, error) {
+	key, ok := intValue(arg[0])
+	if !ok {
+		return nil, fmt.Errorf("First argument to onKey() should be a key")
+	}
+	closure, err := handlerClosure("onKey", arg[1])
+	if err != nil {
+		return nil, err
+	}
+	// only presses from now on count
+	presses := ctx.Video.Keyboard.Presses(glfw.Key(key))
+	return ctx.Events.add(&handler{closure: closure, pos: ctx.Pos, key: key, isKey: true, presses: presses}), nil
+}
+
+func onRaster(ctx *Context, arg ...interface{}) (interface{}, error) {
+	line, ok := intValue(arg[0])
+	border := ctx.Video.Render.Border.Height
+	if !ok || line < -border || line >= gfx.Height+border {
+		return nil, fmt.Errorf("First argument to onRaster() should be a line from %d to %d", -border, gfx.Height+border-1)
+	}
+	closure, err := handlerClosure("onRaster", arg[1])
+	if err != nil {
+		return nil, err
+	}
+	id := ctx.Events.add(&handler{closure: closure, pos: ctx.Pos, raster: true, line: line})
+	ctx.watchRaster()
+	return id, nil
+}
+
+func cancel(ctx *Context, arg ...interface{}) (interface{}, error) {
+	id, ok := intValue(arg[0])
+	if !ok {
+		return nil, fmt.Errorf("argument to cancel() should be the number returned when the handler was registered")
+	}
+	cancelled := ctx.Events.cancel(id)
+	ctx.watchRaster()
+	return cancelled, nil
+}
+
+func setFrameRate(ctx *Context, arg ...interface{}) (interface{}, error) {
+	fps, ok := floatValue(arg[0])
+	if !ok || fps <= 0 {
+		return nil, fmt.Errorf("argument to setFrameRate() should be a positive number")
+	}
+	ctx.Video.Render.SetFps(fps)
+	return nil, nil
+}
@@ -0,0 +1,235 @@
+package bscript
+
+import (
+	"errors"
+	"fmt"
+	"reflect"
+
+	"github.com/alecthomas/participle/lexer"
+)
+
+// Generator is the value returned by calling a function that contains yield.
+// The function's code runs in its own goroutine, but never at the same time as the rest of the program:
+// resume hands the interpreter over to the generator and waits until it yields (or returns) to take it back.
+type Generator struct {
+	// the function's name and where it was called from
+	name string
+	pos  lexer.Position
+	// a copy of the function's closure, so several generators of the same function don't share variables
+	closure *Closure
+
+	started  bool
+	running  bool
+	finished bool
+	// stop() was called: the generator's code is unwinding
+	stopping bool
+
+	// resumed wakes up the generator at its last yield
+	resumed chan bool
+	// yielded hands back the yielded value
+	yielded chan generatorResult
+}
+
+type generatorResult struct {
+	value interface{}
+	err   error
+	// true if the generator returned
+	done bool
+}
+
+// errStopped unwinds a generator's code when it's stopped. catch doesn't catch it, but finally blocks run.
+var errStopped = errors.New("generator stopped")
+
+var (
+	funType     = reflect.TypeOf(Fun{})
+	anonFunType = reflect.TypeOf(AnonFun{})
+	yieldType   = reflect.TypeOf(Yield{})
+)
+
+// containsYield is true if v has a yield in it. Nested functions are not searched: a yield in them makes them generators instead.
+func containsYield(v reflect.Value) bool {
+	switch v.Kind() {
+	case reflect.Ptr:
+		return !v.IsNil() && containsYield(v.Elem())
+	case reflect.Slice:
+		for i := 0; i < v.Len(); i++ {
+			if containsYield(v.Index(i)) {
+				return true
+			}
+		}
+	case reflect.Struct:
+		switch v.Type() {
+		case yieldType:
+			return true
+		case funType, anonFunType, positionType, stringLiteralType:
+			return false
+		}
+		for i := 0; i < v.NumField(); i++ {
+			if containsYield(v.Field(i)) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// newGenerator is called instead of running a generator function. The code doesn't run until the first next().
+func newGenerator(ctx *Context, c *Call, closure *Closure, args []interface{}) (*Generator, error) {
+	g := &Generator{
+		name: c.Name,
+		pos:  c.Pos,
+		closure: &Closure{
+			Params:    closure.Params,
+			Commands:  closure.Commands,
+			Function:  closure.Function,
+			Generator: true,
+			Vars:      map[string]interface{}{},
+			Defs:      map[string]*Closure{},
+			Parent:    closure.Parent,
+			Video:     closure.Video,
+		},
+		resumed: make(chan bool),
+		yielded: make(chan generatorResult),
+	}
+	savedClosure := ctx.Closure
+	ctx.Closure = g.closure
+	err := bindParams(ctx, g.closure, args)
+	ctx.Closure = savedClosure
+	if err != nil {
+		return nil, err
+	}
+	return g, nil
+}
+
+func (g *Generator) String() string {
+	return fmt.Sprintf("generator %s()", g.name)
+}
+
+// resume runs the generator until its next yield. It returns the yielded value, or the returned one if the generator finished.
+func (g *Generator) resume(ctx *Context) (interface{}, error) {
+	if g.finished {
+		return nil, nil
+	}
+	if g.running {
+		return nil, fmt.Errorf("%s is already running", g)
+	}
+
+	// save the caller's state: like any function call, the generator may change the caller's closure
+	closure, stack, pos, generator := ctx.Closure, ctx.RuntimeStack, ctx.Pos, ctx.Generator
+	saved := make(map[string]interface{}, len(closure.Vars))
+	for k, v := range closure.Vars {
+		saved[k] = v
+	}
+
+	ctx.Closure = g.closure
+	ctx.RuntimeStack = append(stack[:len(stack):len(stack)], Runtime{
+		Pos:      g.pos,
+		Function: g.name,
+		Vars:     saved,
+	})
+	ctx.Generator = g
+	g.running = true
+	if g.started {
+		g.resumed <- true
+	} else {
+		g.started = true
+		if ctx.generators == nil {
+			ctx.generators = map[*Generator]bool{}
+		}
+		ctx.generators[g] = true
+		go g.run(ctx)
+	}
+	result := <-g.yielded
+	g.running = false
+	g.finished = result.done
+	if g.finished {
+		delete(ctx.generators, g)
+	}
+
+	ctx.Closure, ctx.RuntimeStack, ctx.Pos, ctx.Generator = closure, stack, pos, generator
+	for k, v := range saved {
+		ctx.Closure.Vars[k] = v
+	}
+	return result.value, result.err
+}
+
+// stop ends a generator that was started but hasn't finished, so its goroutine doesn't wait for next() forever.
+// The yield it's waiting at returns errStopped.
+func (g *Generator) stop(ctx *Context) {
+	if !g.started || g.finished || g.running {
+		return
+	}
+	g.stopping = true
+	g.resume(ctx)
+}
+
+// stopGenerators stops the generators still waiting at a yield, when the program ends
+func (ctx *Context) stopGenerators() {
+	for g := range ctx.generators {
+		g.stop(ctx)
+	}
+	ctx.generators = nil
+	ctx.Coroutines = []*Generator{}
+}
+
+func (g *Generator) run(ctx *Context) {
+	value, err := evalTryBlock(ctx, g.closure.Commands)
+	g.yielded <- generatorResult{value: value, err: err, done: true}
+}
+
+func (y *Yield) Evaluate(ctx *Context) (interface{}, error) {
+	g := ctx.Generator
+	if g == nil || ctx.Closure != g.closure {
+		return nil, lexer.Errorf(y.Pos, "yield can only be used in the function that contains it")
+	}
+	if g.stopping {
+		// a finally block yielding while the generator is stopped
+		return nil, errStopped
+	}
+	var value interface{}
+	if y.Value != nil {
+		var err error
+		value, err = y.Value.Evaluate(ctx)
+		if err != nil {
+			return nil, err
+		}
+	}
+	// hand the interpreter back to resume and wait for the next one
+	g.yielded <- generatorResult{value: value}
+	<-g.resumed
+	if g.stopping {
+		return nil, errStopped
+	}
+	return nil, nil
+}
+
+// runCoroutines resumes each coroutine started with spawn() once, and forgets the finished ones
+func (ctx *Context) runCoroutines() error {
+	if ctx.scheduling {
+		// updateVideo() was called from a coroutine
+		return nil
+	}
+	ctx.scheduling = true
+	defer func() {
+		ctx.scheduling = false
+	}()
+
+	coroutines := ctx.Coroutines
+	ctx.Coroutines = []*Generator{}
+	running := []*Generator{}
+	for index, g := range coroutines {
+		if !g.running {
+			if _, err := g.resume(ctx); err != nil {
+				// keep the others (and the ones spawned meanwhile) for the next frame
+				ctx.Coroutines = append(append(running, coroutines[index+1:]...), ctx.Coroutines...)
+				return err
+			}
+		}
+		if !g.finished {
+			running = append(running, g)
+		}
+	}
+	// add the coroutines spawned by the ones that just ran
+	ctx.Coroutines = append(running, ctx.Coroutines...)
+	return nil
+}
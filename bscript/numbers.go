@@ -0,0 +1,193 @@
+package bscript
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+
+	"github.com/alecthomas/participle/lexer"
+)
+
+// Numbers in bscript are either integers (go int) or floats (go float64).
+// Integer literals are written without a decimal point (10, 0xff), floats with one (1.5, 10.0).
+// Arithmetic on two integers stays integral, except for / which always returns a float.
+
+// NumberLiteral is a number from the source
+type NumberLiteral struct {
+	Value interface{}
+}
+
+// Capture is called by the parser with the number's text
+func (n *NumberLiteral) Capture(values []string) error {
+	s := strings.Join(values, "")
+	if strings.ContainsRune(s, '.') {
+		f, err := strconv.ParseFloat(s, 64)
+		if err != nil {
+			return err
+		}
+		n.Value = f
+		return nil
+	}
+	i, err := strconv.ParseInt(s, 0, 64)
+	if err != nil {
+		return err
+	}
+	n.Value = int(i)
+	return nil
+}
+
+// floatValue returns any number as a float64
+func floatValue(value interface{}) (float64, bool) {
+	switch n := value.(type) {
+	case int:
+		return float64(n), true
+	case float64:
+		return n, true
+	}
+	return 0, false
+}
+
+// intValue returns value as an int, if it is an integer or a float without a fraction
+func intValue(value interface{}) (int, bool) {
+	switch n := value.(type) {
+	case int:
+		return n, true
+	case float64:
+		if n == math.Trunc(n) && !math.IsInf(n, 0) {
+			return int(n), true
+		}
+	}
+	return 0, false
+}
+
+// toIndex converts value to an array index. Unlike a silent truncation, a float with a fraction is an error.
+func toIndex(pos lexer.Position, value interface{}) (int, error) {
+	index, ok := intValue(value)
+	if !ok {
+		if _, isNumber := value.(float64); isNumber {
+			return 0, lexer.Errorf(pos, "Array index should be an integer, not %s (use int() or //)", EvalString(value))
+		}
+		return 0, lexer.Errorf(pos, "Array index should be a number")
+	}
+	return index, nil
+}
+
+// isNumber is true for ints and floats
+func isNumber(value interface{}) bool {
+	_, ok := floatValue(value)
+	return ok
+}
+
+// the smallest int, which has no positive counterpart
+const minInt = -1 << (strconv.IntSize - 1)
+
+// intPow raises base to a power by squaring. It's false if the result doesn't fit in an int.
+func intPow(base, exp int) (int, bool) {
+	result := 1
+	for exp > 0 {
+		if exp&1 == 1 {
+			var ok bool
+			if result, ok = mulInt(result, base); !ok {
+				return 0, false
+			}
+		}
+		exp >>= 1
+		if exp > 0 {
+			var ok bool
+			if base, ok = mulInt(base, base); !ok {
+				return 0, false
+			}
+		}
+	}
+	return result, true
+}
+
+// mulInt multiplies two ints. It's false if the product overflows.
+func mulInt(a, b int) (int, bool) {
+	if a == 0 || b == 0 {
+		return 0, true
+	}
+	c := a * b
+	if c/b != a || (b == -1 && a == minInt) {
+		return 0, false
+	}
+	return c, true
+}
+
+// arithmetic applies a numeric operator. The result is an integer when both operands are integers.
+func arithmetic(operator Operator, lhs, rhs interface{}) (interface{}, error) {
+	lhsInt, lhsIsInt := lhs.(int)
+	rhsInt, rhsIsInt := rhs.(int)
+	if lhsIsInt && rhsIsInt {
+		switch operator {
+		case "+":
+			return lhsInt + rhsInt, nil
+		case "-":
+			return lhsInt - rhsInt, nil
+		case "*":
+			return lhsInt * rhsInt, nil
+		case "/":
+			return float64(lhsInt) / float64(rhsInt), nil
+		case "//":
+			if rhsInt == 0 {
+				return nil, fmt.Errorf("integer division by zero")
+			}
+			// round towards negative infinity, like math.Floor does for floats
+			q := lhsInt / rhsInt
+			if lhsInt%rhsInt != 0 && (lhsInt < 0) != (rhsInt < 0) {
+				q--
+			}
+			return q, nil
+		case "%":
+			if rhsInt == 0 {
+				return nil, fmt.Errorf("integer division by zero")
+			}
+			// the remainder of //, so it takes the sign of the divisor
+			r := lhsInt % rhsInt
+			if r != 0 && (r < 0) != (rhsInt < 0) {
+				r += rhsInt
+			}
+			return r, nil
+		case "**":
+			if rhsInt >= 0 {
+				if result, ok := intPow(lhsInt, rhsInt); ok {
+					return result, nil
+				}
+			}
+			// negative powers are fractions, and too big ones become floats
+			return math.Pow(float64(lhsInt), float64(rhsInt)), nil
+		}
+		panic("unreachable")
+	}
+
+	lhsNumber, ok := floatValue(lhs)
+	if !ok {
+		return nil, fmt.Errorf("lhs must be a number")
+	}
+	rhsNumber, ok := floatValue(rhs)
+	if !ok {
+		return nil, fmt.Errorf("rhs must be a number")
+	}
+	switch operator {
+	case "+":
+		return lhsNumber + rhsNumber, nil
+	case "-":
+		return lhsNumber - rhsNumber, nil
+	case "*":
+		return lhsNumber * rhsNumber, nil
+	case "/":
+		return lhsNumber / rhsNumber, nil
+	case "//":
+		return math.Floor(lhsNumber / rhsNumber), nil
+	case "%":
+		r := math.Mod(lhsNumber, rhsNumber)
+		if r != 0 && (r < 0) != (rhsNumber < 0) {
+			r += rhsNumber
+		}
+		return r, nil
+	case "**":
+		return math.Pow(lhsNumber, rhsNumber), nil
+	}
+	panic("unreachable")
+}
@@ -0,0 +1,125 @@
+package bscript
+
+import (
+	"strings"
+
+	"github.com/alecthomas/participle/lexer"
+)
+
+// VARIADIC as a builtin's MaxArgs means it takes any number of arguments
+const VARIADIC = -1
+
+func (p *Param) String() string {
+	if p.Rest {
+		return "..." + p.Name
+	}
+	return p.Name
+}
+
+func paramNames(params []*Param) string {
+	names := make([]string, len(params))
+	for index, param := range params {
+		names[index] = param.String()
+	}
+	return strings.Join(names, ",")
+}
+
+// checkParams makes sure that parameters with a default value come after the required ones,
+// and that a rest parameter is the last one.
+func checkParams(name string, params []*Param) error {
+	seen := map[string]bool{}
+	hasDefault := false
+	for index, param := range params {
+		if seen[param.Name] {
+			return lexer.Errorf(param.Pos, "parameter %s is declared twice in %s()", param.Name, name)
+		}
+		seen[param.Name] = true
+		switch {
+		case param.Rest:
+			if param.Default != nil {
+				return lexer.Errorf(param.Pos, "rest parameter ...%s can't have a default value", param.Name)
+			}
+			if index != len(params)-1 {
+				return lexer.Errorf(param.Pos, "rest parameter ...%s should be the last parameter of %s()", param.Name, name)
+			}
+		case param.Default != nil:
+			hasDefault = true
+		case hasDefault:
+			return lexer.Errorf(param.Pos, "parameter %s of %s() needs a default value, because the one before it has one", param.Name, name)
+		}
+	}
+	return nil
+}
+
+// arity returns how many arguments a function with these parameters takes
+func arity(params []*Param) (int, int) {
+	min := 0
+	for _, param := range params {
+		if param.Rest {
+			return min, VARIADIC
+		}
+		if param.Default == nil {
+			min++
+		}
+	}
+	return min, len(params)
+}
+
+// checkArgCount reports a call with the wrong number of arguments. It is used for builtins and bscript functions alike.
+func checkArgCount(pos lexer.Position, name string, min, max, count int) error {
+	if count >= min && (max == VARIADIC || count <= max) {
+		return nil
+	}
+	switch {
+	case min == max:
+		return lexer.Errorf(pos, "%s() takes %d argument(s), not %d", name, min, count)
+	case max == VARIADIC:
+		return lexer.Errorf(pos, "%s() takes at least %d argument(s), not %d", name, min, count)
+	}
+	return lexer.Errorf(pos, "%s() takes %d to %d arguments, not %d", name, min, max, count)
+}
+
+// bindParams creates the parameter variables of a call in the function's closure.
+// Default values are evaluated in the function's closure, so they can refer to the parameters before them.
+func bindParams(ctx *Context, closure *Closure, args []interface{}) error {
+	for index, param := range closure.Params {
+		switch {
+		case param.Rest:
+			rest := []interface{}{}
+			if index < len(args) {
+				rest = append(rest, args[index:]...)
+			}
+			closure.Vars[param.Name] = &rest
+		case index < len(args):
+			closure.Vars[param.Name] = args[index]
+		default:
+			value, err := param.Default.Evaluate(ctx)
+			if err != nil {
+				return err
+			}
+			closure.Vars[param.Name] = value
+		}
+	}
+	return nil
+}
+
+// evalArgs evaluates the arguments of a call, expanding spread arguments
+func (callParams *CallParams) evalArgs(ctx *Context) ([]interface{}, error) {
+	args := []interface{}{}
+	for _, arg := range callParams.Args {
+		value, err := arg.Value.Evaluate(ctx)
+		if err != nil {
+			return nil, err
+		}
+		if !arg.Spread {
+			args = append(args, value)
+			continue
+		}
+		a, ok := value.(*[]interface{})
+		if !ok {
+			return nil, lexer.Errorf(arg.Pos, "only an array can be spread into arguments, not %s", EvalString(value))
+		}
+		args = append(args, *a...)
+	}
+	return args, nil
+}
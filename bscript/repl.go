@@ -14,11 +14,11 @@ func processCommand(ctx *Context, cmds string) (bool, error) {
 	cmd := strings.Split(cmds, " ")
 	switch {
 	case cmd[0] == "exit":
-		ctx.Builtins["print"](ctx, "Goodbye.")
+		ctx.Builtins["print"].Fn(ctx, "Goodbye.")
 		os.Exit(0)
 		return true, nil
 	case cmd[0] == "debug":
-		ctx.Builtins["debug"](ctx, "State in repl:")
+		ctx.Builtins["debug"].Fn(ctx, "State in repl:")
 		return true, nil
 	case cmd[0] == "run":
 		var err error
@@ -34,12 +34,12 @@ func processCommand(ctx *Context, cmds string) (bool, error) {
 		_, err := Load(cmd[1], nil, ctx)
 		return true, err
 	case cmd[0] == "help":
-		ctx.Builtins["print"](ctx, "bscript Repl commands:")
-		ctx.Builtins["print"](ctx, "exit - quit to shell")
-		ctx.Builtins["print"](ctx, "run [<filename>] - if filename is given, load and run the program specified by filename. Without a filename: run program currently in memory.")
-		ctx.Builtins["print"](ctx, "load <filename> - load the program specified by filename")
-		ctx.Builtins["print"](ctx, "help - print this help")
-		ctx.Builtins["print"](ctx, "debug - print stack and closures")
+		ctx.Builtins["print"].Fn(ctx, "bscript Repl commands:")
+		ctx.Builtins["print"].Fn(ctx, "exit - quit to shell")
+		ctx.Builtins["print"].Fn(ctx, "run [<filename>] - if filename is given, load and run the program specified by filename. Without a filename: run program currently in memory.")
+		ctx.Builtins["print"].Fn(ctx, "load <filename> - load the program specified by filename")
+		ctx.Builtins["print"].Fn(ctx, "help - print this help")
+		ctx.Builtins["print"].Fn(ctx, "debug - print stack and closures")
 		return true, nil
 	default:
 		return false, nil
@@ -51,20 +51,20 @@ func Repl(video *gfx.Gfx) {
 	ctx := CreateContext(nil)
 	ctx.Video = video
 
-	ctx.Builtins["print"](ctx, "     **** Benji4000 bscript v1 ****")
-	ctx.Builtins["print"](ctx, "")
+	ctx.Builtins["print"].Fn(ctx, "     **** Benji4000 bscript v1 ****")
+	ctx.Builtins["print"].Fn(ctx, "")
 	for true {
-		ctx.Builtins["print"](ctx, "Ready.")
-		command, err := ctx.Builtins["input"](ctx, "")
+		ctx.Builtins["print"].Fn(ctx, "Ready.")
+		command, err := ctx.Builtins["input"].Fn(ctx, "")
 		if err != nil {
-			ctx.Builtins["print"](ctx, syntaxError)
+			ctx.Builtins["print"].Fn(ctx, syntaxError)
 		}
 
 		ast := &Command{}
 		handled, err := processCommand(ctx, command.(string))
 		if err != nil {
-			ctx.Builtins["print"](ctx, fmt.Sprintf("%s: %s", syntaxError, err))
-		} else if handled == false {
+			ctx.Builtins["print"].Fn(ctx, fmt.Sprintf("%s: %s", syntaxError, err))
+		} else if handled == false && !onlyComments(command.(string)) {
 			err = CommandParser.ParseString(command.(string), ast)
 			if err != nil {
 				// try a few things to make it compile
@@ -83,14 +83,14 @@ func Repl(video *gfx.Gfx) {
 				// repr.Println(ast)
 				value, err := ast.Evaluate(ctx)
 				if err != nil {
-					ctx.Builtins["print"](ctx, fmt.Sprintf("%s: %s", syntaxError, err))
+					ctx.Builtins["print"].Fn(ctx, fmt.Sprintf("%s: %s", syntaxError, err))
 				}
 				if value != nil {
-					ctx.Builtins["print"](ctx, fmt.Sprintf("%v", value))
+					ctx.Builtins["print"].Fn(ctx, fmt.Sprintf("%v", value))
 				}
 			}
 		}
 
-		ctx.Builtins["print"](ctx, "")
+		ctx.Builtins["print"].Fn(ctx, "")
 	}
 }
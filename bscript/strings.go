@@ -0,0 +1,209 @@
+package bscript
+
+import (
+	"fmt"
+	"reflect"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/alecthomas/participle/lexer"
+)
+
+// StringPart is either literal text or an interpolated ${expression}
+type StringPart struct {
+	Pos lexer.Position
+
+	Text       string
+	Expression *Expression
+}
+
+// StringLiteral is a quoted string from the source: escapes are decoded and ${...} expressions are parsed
+type StringLiteral struct {
+	Pos lexer.Position
+
+	Parts []*StringPart
+	// a malformed escape or ${...} expression. The parser would only report a generic
+	// syntax error, so this is reported when the string is evaluated instead.
+	Error error
+}
+
+// Capture is called by the parser with the quoted source text of the string
+func (s *StringLiteral) Capture(values []string) error {
+	s.Error = s.compile(strings.Join(values, ""))
+	return nil
+}
+
+func (s *StringLiteral) compile(raw string) error {
+	pos := s.Pos
+	var body string
+	if strings.HasPrefix(raw, `"""`) {
+		body = raw[3 : len(raw)-3]
+		pos = advance(pos, `"""`)
+		// a multi-line string may start on the line after the opening quotes
+		if strings.HasPrefix(body, "\n") {
+			body = body[1:]
+			pos = advance(pos, "\n")
+		}
+	} else {
+		body = raw[1 : len(raw)-1]
+		pos = advance(pos, `"`)
+	}
+
+	s.Parts = []*StringPart{}
+	var text strings.Builder
+	textPos := pos
+	for len(body) > 0 {
+		switch {
+		case body[0] == '\\':
+			r, size, err := unescape(body)
+			if err != nil {
+				return lexer.Errorf(pos, "%s", err)
+			}
+			text.WriteRune(r)
+			pos = advance(pos, body[:size])
+			body = body[size:]
+		case strings.HasPrefix(body, "${"):
+			end := interpolationEnd(body)
+			if end < 0 {
+				return lexer.Errorf(pos, "unterminated ${ in string")
+			}
+			if text.Len() > 0 {
+				s.Parts = append(s.Parts, &StringPart{Pos: textPos, Text: text.String()})
+				text.Reset()
+			}
+			exprPos := advance(pos, "${")
+			expr := &Expression{}
+			if err := ExpressionParser.ParseString(body[2:end], expr); err != nil {
+				if lerr, ok := err.(*lexer.Error); ok {
+					return lexer.Errorf(shift(lerr.Pos, exprPos), "%s", lerr.Message)
+				}
+				return lexer.Errorf(exprPos, "%s", err)
+			}
+			shiftPositions(reflect.ValueOf(expr), exprPos)
+			s.Parts = append(s.Parts, &StringPart{Pos: pos, Expression: expr})
+			pos = advance(pos, body[:end+1])
+			body = body[end+1:]
+			textPos = pos
+		default:
+			_, size := utf8.DecodeRuneInString(body)
+			text.WriteString(body[:size])
+			pos = advance(pos, body[:size])
+			body = body[size:]
+		}
+	}
+	if text.Len() > 0 || len(s.Parts) == 0 {
+		s.Parts = append(s.Parts, &StringPart{Pos: textPos, Text: text.String()})
+	}
+	return nil
+}
+
+// unescape decodes the escape sequence at the start of s. It returns the rune and the length of the sequence.
+func unescape(s string) (rune, int, error) {
+	if len(s) < 2 {
+		return 0, 0, fmt.Errorf("unterminated escape sequence")
+	}
+	switch s[1] {
+	case 'n':
+		return '\n', 2, nil
+	case 't':
+		return '\t', 2, nil
+	case 'r':
+		return '\r', 2, nil
+	case '"', '\\', '$':
+		return rune(s[1]), 2, nil
+	case 'x':
+		if len(s) < 4 {
+			return 0, 0, fmt.Errorf("\\x needs two hex digits")
+		}
+		n, err := strconv.ParseUint(s[2:4], 16, 8)
+		if err != nil {
+			return 0, 0, fmt.Errorf("\\x needs two hex digits")
+		}
+		return rune(n), 4, nil
+	case 'u':
+		end := strings.IndexByte(s, '}')
+		if len(s) < 3 || s[2] != '{' || end < 0 {
+			return 0, 0, fmt.Errorf("\\u needs a hex code in braces, like \\u{2665}")
+		}
+		n, err := strconv.ParseUint(s[3:end], 16, 32)
+		if err != nil || !utf8.ValidRune(rune(n)) {
+			return 0, 0, fmt.Errorf("invalid code point in %s", s[:end+1])
+		}
+		return rune(n), end + 1, nil
+	}
+	return 0, 0, fmt.Errorf("unknown escape sequence \\%c", s[1])
+}
+
+// interpolationEnd returns the index of the } closing the ${ at the start of s, or -1
+func interpolationEnd(s string) int {
+	depth := 0
+	for i := 2; i < len(s); i++ {
+		switch s[i] {
+		case '"':
+			// skip over strings inside the expression
+			for i++; i < len(s) && s[i] != '"'; i++ {
+				if s[i] == '\\' {
+					i++
+				}
+			}
+		case '{':
+			depth++
+		case '}':
+			if depth == 0 {
+				return i
+			}
+			depth--
+		}
+	}
+	return -1
+}
+
+// advance moves pos past text
+func advance(pos lexer.Position, text string) lexer.Position {
+	for _, r := range text {
+		pos.Offset += utf8.RuneLen(r)
+		if r == '\n' {
+			pos.Line++
+			pos.Column = 1
+		} else {
+			pos.Column++
+		}
+	}
+	return pos
+}
+
+// shift turns pos, which is relative to an interpolated expression's text, into a position in the source file
+func shift(pos lexer.Position, base lexer.Position) lexer.Position {
+	if pos.Line == 1 {
+		pos.Column += base.Column - 1
+	}
+	pos.Line += base.Line - 1
+	pos.Offset += base.Offset
+	pos.Filename = base.Filename
+	return pos
+}
+
+var positionType = reflect.TypeOf(lexer.Position{})
+
+// shiftPositions calls shift on every position in the AST below v
+func shiftPositions(v reflect.Value, base lexer.Position) {
+	switch v.Kind() {
+	case reflect.Ptr:
+		if !v.IsNil() {
+			shiftPositions(v.Elem(), base)
+		}
+	case reflect.Slice:
+		for i := 0; i < v.Len(); i++ {
+			shiftPositions(v.Index(i), base)
+		}
+	case reflect.Struct:
+		if v.Type() == positionType {
+			v.Set(reflect.ValueOf(shift(v.Interface().(lexer.Position), base)))
+			return
+		}
+		for i := 0; i < v.NumField(); i++ {
+			shiftPositions(v.Field(i), base)
+		}
+	}
+}
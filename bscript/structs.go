@@ -0,0 +1,65 @@
+package bscript
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/alecthomas/participle/lexer"
+)
+
+// StructValue is an instance of a struct declared with: struct Player { x, y, lives }
+type StructValue struct {
+	Type *Struct
+	// the field values, in the order the fields were declared
+	Values []interface{}
+}
+
+func (s *Struct) Evaluate(ctx *Context) (interface{}, error) {
+	if _, ok := ctx.Structs[s.Name]; ok {
+		return nil, lexer.Errorf(s.Pos, "struct %s is already declared", s.Name)
+	}
+	if _, ok := ctx.Builtins[s.Name]; ok {
+		return nil, lexer.Errorf(s.Pos, "struct %s has the same name as a builtin function", s.Name)
+	}
+	for i, field := range s.Fields {
+		for _, other := range s.Fields[:i] {
+			if other == field {
+				return nil, lexer.Errorf(s.Pos, "struct %s declares field %s twice", s.Name, field)
+			}
+		}
+	}
+	ctx.Structs[s.Name] = s
+	return nil, nil
+}
+
+// construct creates a new instance, args are the field values in declaration order
+func (s *Struct) construct(pos lexer.Position, args []interface{}) (*StructValue, error) {
+	if len(args) != len(s.Fields) {
+		return nil, lexer.Errorf(pos, "%s() needs a value for each field: %s", s.Name, strings.Join(s.Fields, ", "))
+	}
+	values := make([]interface{}, len(args))
+	copy(values, args)
+	return &StructValue{Type: s, Values: values}, nil
+}
+
+// field returns the index of a field in Values
+func (s *StructValue) field(pos lexer.Position, key interface{}) (int, error) {
+	name, ok := key.(string)
+	if !ok {
+		return 0, lexer.Errorf(pos, "Struct field should be a name")
+	}
+	for index, field := range s.Type.Fields {
+		if field == name {
+			return index, nil
+		}
+	}
+	return 0, lexer.Errorf(pos, "struct %s has no field %s", s.Type.Name, name)
+}
+
+func (s *StructValue) String() string {
+	fields := make([]string, len(s.Values))
+	for index, value := range s.Values {
+		fields[index] = fmt.Sprintf("%s: %s", s.Type.Fields[index], EvalString(value))
+	}
+	return fmt.Sprintf("%s{%s}", s.Type.Name, strings.Join(fields, ", "))
+}
@@ -0,0 +1,84 @@
+package gfx
+
+import (
+	"fmt"
+	"image"
+	"image/png"
+	"io"
+	"io/ioutil"
+)
+
+// Each machine has its own copy of the font, so programs can redefine the glyphs.
+// A glyph is 8 rows of 8 pixels, one byte per row: bit 0 is the leftmost pixel.
+
+// GlyphCount is how many glyphs the font has
+const GlyphCount = len(Font8x8)
+
+// SetGlyph redefines the glyph for code
+func (gfx *Gfx) SetGlyph(code int, rows [8]uint8) error {
+	if code < 0 || code >= GlyphCount {
+		return fmt.Errorf("no glyph %d, the glyphs are 0-%d", code, GlyphCount-1)
+	}
+	gfx.Font[code] = rows
+	return nil
+}
+
+// GetGlyph returns the rows of the glyph for code
+func (gfx *Gfx) GetGlyph(code int) ([8]uint8, error) {
+	if code < 0 || code >= GlyphCount {
+		return [8]uint8{}, fmt.Errorf("no glyph %d, the glyphs are 0-%d", code, GlyphCount-1)
+	}
+	return gfx.Font[code], nil
+}
+
+// LoadCharset reads glyphs in the raw format (8 bytes per glyph, like the font memory) into the font, starting at glyph 0.
+// It returns the number of glyphs read.
+func (gfx *Gfx) LoadCharset(r io.Reader) (int, error) {
+	data, err := ioutil.ReadAll(r)
+	if err != nil {
+		return 0, err
+	}
+	if len(data)%8 != 0 || len(data) > GlyphCount*8 {
+		return 0, fmt.Errorf("a charset should have 8 bytes per glyph and at most %d glyphs, not %d bytes", GlyphCount, len(data))
+	}
+	for code := 0; code < len(data)/8; code++ {
+		copy(gfx.Font[code][:], data[code*8:code*8+8])
+	}
+	return len(data) / 8, nil
+}
+
+// LoadCharsetPNG reads glyphs from a PNG sheet of 8x8 cells into the font: the glyphs go left to right, then top to bottom,
+// starting at glyph 0. A pixel is set if it's bright and opaque. It returns the number of glyphs read.
+func (gfx *Gfx) LoadCharsetPNG(r io.Reader) (int, error) {
+	img, err := png.Decode(r)
+	if err != nil {
+		return 0, err
+	}
+	bounds := img.Bounds()
+	columns, rows := bounds.Dx()/8, bounds.Dy()/8
+	if columns == 0 || rows == 0 || bounds.Dx()%8 != 0 || bounds.Dy()%8 != 0 {
+		return 0, fmt.Errorf("a charset sheet should be a grid of 8x8 glyphs, not %dx%d pixels", bounds.Dx(), bounds.Dy())
+	}
+	count := columns * rows
+	if count > GlyphCount {
+		count = GlyphCount
+	}
+	for code := 0; code < count; code++ {
+		gfx.Font[code] = glyphAt(img, bounds.Min.X+(code%columns)*8, bounds.Min.Y+(code/columns)*8)
+	}
+	return count, nil
+}
+
+func glyphAt(img image.Image, x, y int) [8]uint8 {
+	glyph := [8]uint8{}
+	for row := 0; row < 8; row++ {
+		for bit := 0; bit < 8; bit++ {
+			r, g, b, a := img.At(x+bit, y+row).RGBA()
+			// the colors are premultiplied by alpha, so a transparent pixel is dark
+			if (r+g+b)/3 >= 0x8000 && a >= 0x8000 {
+				glyph[row] |= 1 << uint(bit)
+			}
+		}
+	}
+	return glyph
+}
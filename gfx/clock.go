@@ -0,0 +1,78 @@
+package gfx
+
+import (
+	"sync"
+	"time"
+
+	"github.com/go-gl/glfw/v3.2/glfw"
+)
+
+// Clock is the machine's time. getTicks() and the timers read it, and UpdateVideo() tells it about every frame.
+type Clock interface {
+	// Ticks is the time in seconds
+	Ticks() float64
+	// Frame is called once per UpdateVideo()
+	Frame()
+	// Sleep waits until the clock moved by seconds
+	Sleep(seconds float64)
+}
+
+// RealClock is the time of the computer it runs on
+type RealClock struct{}
+
+func (RealClock) Ticks() float64 {
+	return glfw.GetTime()
+}
+
+func (RealClock) Frame() {}
+
+func (RealClock) Sleep(seconds float64) {
+	time.Sleep(time.Duration(seconds * float64(time.Second)))
+}
+
+// SteppedClock only moves when a frame is shown, by the same amount each time.
+// A program using it behaves the same on every run, however fast the computer is.
+type SteppedClock struct {
+	lock sync.Mutex
+	// how far the clock moves per frame, in seconds
+	Step  float64
+	ticks float64
+}
+
+func NewSteppedClock(step float64) *SteppedClock {
+	return &SteppedClock{Step: step}
+}
+
+func (clock *SteppedClock) Ticks() float64 {
+	clock.lock.Lock()
+	defer clock.lock.Unlock()
+	return clock.ticks
+}
+
+func (clock *SteppedClock) Frame() {
+	clock.Advance(clock.Step)
+}
+
+// Sleep doesn't wait: it moves the clock forward
+func (clock *SteppedClock) Sleep(seconds float64) {
+	clock.Advance(seconds)
+}
+
+// Advance moves the clock forward by seconds, without showing a frame
+func (clock *SteppedClock) Advance(seconds float64) {
+	clock.lock.Lock()
+	clock.ticks += seconds
+	clock.lock.Unlock()
+}
+
+// GetTicks is the time in seconds, according to the machine's clock
+func (gfx *Gfx) GetTicks() float64 {
+	return gfx.Clock.Ticks()
+}
+
+// FrameCount is the number of frames shown: how many times UpdateVideo() was called
+func (gfx *Gfx) FrameCount() int {
+	gfx.Render.Lock.Lock()
+	defer gfx.Render.Lock.Unlock()
+	return gfx.Render.Frame
+}
@@ -9,6 +9,9 @@ const (
 	Width  = 320
 	Height = 200
 
+	// the size of the border around the display, on each side, unless WithBorder() changes it
+	DefaultBorder = 32
+
 	// GfxTextMode is a 40x25 char text mode, 16 color background, 16 color foreground
 	GfxTextMode = 0
 
@@ -29,17 +32,35 @@ type Cursor struct {
 type Gfx struct {
 	// the video mode
 	VideoMode int
+	// the machine's memory: the fields below are views onto it, see MemoryMap
+	Memory *Memory
 	// video memory
-	VideoMemory [Width * Height]byte
+	VideoMemory VideoMemory
 	// text memory
-	TextMemory [Width / 8 * Height / 8]int32
+	TextMemory TextMemory
+	// color memory: the colors of each char in text memory, the foreground in the low 4 bits, the background in the high 4 bits.
+	// In text mode the screen is drawn from text and color memory, and the video memory isn't shown.
+	ColorMemory []uint8
 	// the actual renderer
 	Render *Render
+	// the keyboard state and its event queue
+	Keyboard *Keyboard
+	// the mouse state and its event queue
+	Mouse *Mouse
+	// the time, see GetTicks()
+	Clock Clock
 	// Color definitions
-	Colors [16 * 3]uint8
+	Colors *[16 * 3]uint8
 	// the global background color
-	BackgroundColor byte
-	// font memory
+	BackgroundColor *byte
+	// the color of the border around the display
+	BorderColor *byte
+	// OnScanline is called by UpdateVideo() before it draws each line of the frame, with the line's y on the display
+	// (negative in the top border). It can change the video mode, colors or scroll offset for the lines below.
+	OnScanline func(y int) error
+	// the frame being drawn by UpdateVideo()
+	frame []byte
+	// font memory: a copy of Font8x8, so it can be redefined
 	Font *[512][8]uint8
 	// the cursor in interactive mode
 	Cursor *Cursor
@@ -66,38 +87,53 @@ const (
 
 const CURSOR_FONT = 128 + 3
 
-// NewGfx lets you create a new Gfx video card
-func NewGfx() *Gfx {
-	videoMemory := [Width * Height]byte{}
-	for i := range videoMemory {
-		videoMemory[i] = COLOR_LIGHT_BLUE
+// tab stops are this many characters apart
+const TAB_WIDTH = 4
+
+// Option is a setting of a new video card, see NewGfx()
+type Option func(*Border)
+
+// Border is the size of the border around the display, in pixels on each side
+type Border struct {
+	Width, Height int
+}
+
+// WithBorder sets the size of the border around the display, in pixels on each side
+func WithBorder(width, height int) Option {
+	return func(border *Border) {
+		border.Width, border.Height = clamp(width, 0, Width), clamp(height, 0, Height)
 	}
+}
+
+func makeBorder(options []Option) Border {
+	border := Border{Width: DefaultBorder, Height: DefaultBorder}
+	for _, option := range options {
+		option(&border)
+	}
+	return border
+}
+
+// NewGfx lets you create a new Gfx video card
+func NewGfx(options ...Option) *Gfx {
+	keyboard := NewKeyboard()
+	mouse := NewMouse()
+	return newGfx(NewRender(makeBorder(options), keyboard, mouse), keyboard, mouse)
+}
+
+// NewHeadlessGfx creates a video card without a window, for tests and tools.
+// Nothing is shown, but everything else (memory, keyboard, mouse) works.
+func NewHeadlessGfx(options ...Option) *Gfx {
+	return newGfx(newRender(makeBorder(options)), NewKeyboard(), NewMouse())
+}
+
+func newGfx(render *Render, keyboard *Keyboard, mouse *Mouse) *Gfx {
 	gfx := &Gfx{
-		VideoMode:   GfxTextMode,
-		VideoMemory: videoMemory,
-		TextMemory:  [Width / 8 * Height / 8]int32{},
-		Render:      NewRender(),
-		Colors: [16 * 3]uint8{
-			// C64 colors :-)
-			0x00, 0x00, 0x00,
-			0xff, 0xff, 0xff,
-			0x88, 0x20, 0x00,
-			0x68, 0xd0, 0xa8,
-			0xa8, 0x38, 0xa0,
-			0x50, 0xb8, 0x18,
-			0x18, 0x10, 0x90,
-			0xf0, 0xe8, 0x58,
-			0xa0, 0x48, 0x00,
-			0x47, 0x2b, 0x1b,
-			0xc8, 0x78, 0x70,
-			0x48, 0x48, 0x48,
-			0x80, 0x80, 0x80,
-			0x98, 0xff, 0x98,
-			0x50, 0x90, 0xd0,
-			0xb8, 0xb8, 0xb8,
-		},
-		BackgroundColor: COLOR_LIGHT_BLUE,
-		Font:            &Font8x8,
+		VideoMode: GfxTextMode,
+		Memory:    &Memory{},
+		Render:    render,
+		Keyboard:  keyboard,
+		Mouse:     mouse,
+		Clock:     RealClock{},
 		Cursor: &Cursor{
 			X:  0,
 			Y:  0,
@@ -106,6 +142,16 @@ func NewGfx() *Gfx {
 		},
 	}
 	gfx.Cursor.Gfx = gfx
+	gfx.frame = make([]byte, len(render.PixelMemory))
+	gfx.mapMemory()
+	*gfx.Colors = defaultColors
+	*gfx.BackgroundColor = COLOR_LIGHT_BLUE
+	*gfx.BorderColor = COLOR_LIGHT_BLUE
+	*gfx.Font = Font8x8
+	gfx.VideoMemory.Fill(COLOR_LIGHT_BLUE)
+	for i := range gfx.ColorMemory {
+		gfx.ColorMemory[i] = gfx.blankCell()
+	}
 	return gfx
 }
 
@@ -215,10 +261,17 @@ func (gfx *Gfx) DrawText(x, y int, text string, fg, bg uint8) error {
 }
 
 func (gfx *Gfx) DrawFont(x, y int, ch rune, fg, bg uint8) error {
+	if ch < 0 || int(ch) >= len(*gfx.Font) {
+		// no such glyph
+		ch = '?'
+	}
 	if gfx.VideoMode == GfxTextMode {
-		if x >= 0 && y >= 0 && x < Width/8 && y < Height/8 && ch >= 0 {
-			gfx.TextMemory[y*40+x] = ch
+		// the pixels are drawn by UpdateVideo()
+		if x >= 0 && y >= 0 && x < Width/8 && y < Height/8 {
+			gfx.TextMemory.Set(y*40+x, ch)
+			gfx.ColorMemory[y*40+x] = cellColor(fg, bg)
 		}
+		return nil
 	}
 	for row := 0; row < 8; row++ {
 		symbolRow := (*gfx.Font)[ch][row]
@@ -227,20 +280,39 @@ func (gfx *Gfx) DrawFont(x, y int, ch rune, fg, bg uint8) error {
 			if (symbolRow>>bit)&1 == 1 {
 				color = fg
 			}
-			if gfx.VideoMode == GfxTextMode {
-				px := x*8 + bit
-				py := y*8 + row
-				if px >= 0 && py >= 0 && px < Width && py < Height {
-					gfx.VideoMemory[py*Width+px] = color
-				}
-			} else {
-				gfx.SetPixel(x+bit, y+row, color)
-			}
+			gfx.SetPixel(x+bit, y+row, color)
 		}
 	}
 	return nil
 }
 
+func cellColor(fg, bg uint8) uint8 {
+	return fg&0x0f | bg<<4
+}
+
+// the colors of an empty cell
+func (gfx *Gfx) blankCell() uint8 {
+	return cellColor(gfx.Cursor.Fg, *gfx.BackgroundColor)
+}
+
+// SetCellColor changes the colors of a char in text memory
+func (gfx *Gfx) SetCellColor(x, y int, fg, bg uint8) error {
+	if x < 0 || y < 0 || x >= Width/8 || y >= Height/8 {
+		return fmt.Errorf("no cell at %d,%d, the text screen is %dx%d", x, y, Width/8, Height/8)
+	}
+	gfx.ColorMemory[y*40+x] = cellColor(fg, bg)
+	return nil
+}
+
+// GetCell returns a char in text memory and its colors
+func (gfx *Gfx) GetCell(x, y int) (rune, uint8, uint8, error) {
+	if x < 0 || y < 0 || x >= Width/8 || y >= Height/8 {
+		return 0, 0, 0, fmt.Errorf("no cell at %d,%d, the text screen is %dx%d", x, y, Width/8, Height/8)
+	}
+	color := gfx.ColorMemory[y*40+x]
+	return gfx.TextMemory.Get(y*40 + x), color & 0x0f, color >> 4, nil
+}
+
 func (gfx *Gfx) SetPixel(x, y int, fg uint8) error {
 	switch {
 	case gfx.VideoMode == GfxTextMode:
@@ -248,17 +320,17 @@ func (gfx *Gfx) SetPixel(x, y int, fg uint8) error {
 	case gfx.VideoMode == GfxHiresMode:
 		if x >= 0 && y >= 0 && x < Width && y < Height {
 			// set the pixel asked for
-			gfx.VideoMemory[y*Width+x] = byte(fg)
+			gfx.VideoMemory.Set(y*Width+x, fg)
 
-			if fg != gfx.BackgroundColor {
+			if fg != *gfx.BackgroundColor {
 				// set other pixels (if >0) in this 8x8 area
 				bx := (x / 8) * 8
 				by := (y / 8) * 8
 				for xx := 0; xx < 8; xx++ {
 					for yy := 0; yy < 8; yy++ {
 						addr := (by+yy)*Width + (bx + xx)
-						if gfx.VideoMemory[addr] != gfx.BackgroundColor {
-							gfx.VideoMemory[addr] = byte(fg)
+						if gfx.VideoMemory.Get(addr) != *gfx.BackgroundColor {
+							gfx.VideoMemory.Set(addr, fg)
 						}
 					}
 				}
@@ -266,21 +338,84 @@ func (gfx *Gfx) SetPixel(x, y int, fg uint8) error {
 		}
 	case gfx.VideoMode == GfxMultiColorMode:
 		if x >= 0 && y >= 0 && x < Width/2 && y < Height {
-			gfx.VideoMemory[y*Width+x*2] = byte(fg)
-			gfx.VideoMemory[y*Width+x*2+1] = byte(fg)
+			gfx.VideoMemory.Set(y*Width+x*2, fg)
+			gfx.VideoMemory.Set(y*Width+x*2+1, fg)
 		}
 	}
 	return nil
 }
 
+// ScreenSize is the size of the screen in pixels of the current video mode
+func (gfx *Gfx) ScreenSize() (int, int) {
+	if gfx.VideoMode == GfxMultiColorMode {
+		return Width / 2, Height
+	}
+	return Width, Height
+}
+
+// GetPixel returns the color of a pixel, in the coordinates SetPixel uses in the current video mode
+func (gfx *Gfx) GetPixel(x, y int) (uint8, error) {
+	width, height := gfx.ScreenSize()
+	if x < 0 || y < 0 || x >= width || y >= height {
+		return 0, fmt.Errorf("no pixel at %d,%d, the screen is %dx%d", x, y, width, height)
+	}
+	switch gfx.VideoMode {
+	case GfxTextMode:
+		return gfx.textPixel(x, y), nil
+	case GfxMultiColorMode:
+		return gfx.VideoMemory.Get(y*Width + x*2), nil
+	}
+	return gfx.VideoMemory.Get(y*Width + x), nil
+}
+
+// textPixel is the color of a pixel of the text screen, drawn from its char
+func (gfx *Gfx) textPixel(x, y int) uint8 {
+	cell := y/8*40 + x/8
+	ch := gfx.TextMemory.Get(cell)
+	if ch < 0 || int(ch) >= len(*gfx.Font) {
+		ch = '?'
+	}
+	if ((*gfx.Font)[ch][y%8]>>uint(x%8))&1 == 1 {
+		return gfx.ColorMemory[cell] & 0x0f
+	}
+	return gfx.ColorMemory[cell] >> 4
+}
+
+// Screen returns a copy of the screen's pixels, a row at a time, in the coordinates of the current video mode
+func (gfx *Gfx) Screen() []uint8 {
+	width, height := gfx.ScreenSize()
+	screen := make([]uint8, 0, width*height)
+	for y := 0; y < height; y++ {
+		for x := 0; x < width; x++ {
+			color, _ := gfx.GetPixel(x, y)
+			screen = append(screen, color)
+		}
+	}
+	return screen
+}
+
 func (gfx *Gfx) Println(message string, printNewLine bool) error {
 	for _, r := range message {
-		err := gfx.DrawFont(gfx.Cursor.X, gfx.Cursor.Y, r, gfx.Cursor.Fg, gfx.Cursor.Bg)
-		if err != nil {
-			return err
+		switch r {
+		case '\n':
+			gfx.Cursor.NewLine()
+			continue
+		case '\t':
+			// move to the next tab stop
+			gfx.Cursor.draw(false)
+			gfx.Cursor.X = (gfx.Cursor.X/TAB_WIDTH + 1) * TAB_WIDTH
+		case '\r':
+			gfx.Cursor.draw(false)
+			gfx.Cursor.X = 0
+			continue
+		default:
+			err := gfx.DrawFont(gfx.Cursor.X, gfx.Cursor.Y, r, gfx.Cursor.Fg, gfx.Cursor.Bg)
+			if err != nil {
+				return err
+			}
+			gfx.Cursor.X++
 		}
-		gfx.Cursor.X++
-		if gfx.Cursor.X > Width/8 {
+		if gfx.Cursor.X >= Width/8 {
 			gfx.Cursor.NewLine()
 		}
 	}
@@ -324,73 +459,126 @@ func (cursor *Cursor) NewLine() {
 	}
 }
 
+// Scroll moves the screen by dx, dy pixels. In text mode it moves the text and color memory by whole chars.
 func (gfx *Gfx) Scroll(dx, dy int) error {
-	if dy > 0 {
-		offset := dy * Width
-		copy(gfx.VideoMemory[offset:], gfx.VideoMemory[0:Width*Height-offset])
-		for y := 0; y < dy; y++ {
-			for x := 0; x < Width; x++ {
-				gfx.VideoMemory[y*Width+x] = gfx.BackgroundColor
-			}
-		}
-	} else if dy < 0 {
-		offset := -dy * Width
-		copy(gfx.VideoMemory[:], gfx.VideoMemory[offset:Width*Height])
-		offset = (Height + dy) * Width
-		for y := 0; y < -dy; y++ {
-			for x := 0; x < Width; x++ {
-				gfx.VideoMemory[offset+y*Width+x] = gfx.BackgroundColor
-			}
-		}
+	if gfx.VideoMode == GfxTextMode {
+		gfx.scrollText(dx/8, dy/8)
+		return nil
 	}
 	if gfx.VideoMode == GfxMultiColorMode {
 		dx *= 2
 	}
-	if dx > 0 {
-		for y := 0; y < Height; y++ {
-			offset := y * Width
-			copy(gfx.VideoMemory[offset+dx:], gfx.VideoMemory[offset:offset+Width-dx])
-		}
-		for y := 0; y < Height; y++ {
-			for x := 0; x < dx; x++ {
-				gfx.VideoMemory[y*Width+x] = gfx.BackgroundColor
+	video := VideoMemory(append([]byte{}, gfx.VideoMemory...))
+	for y := 0; y < Height; y++ {
+		for x := 0; x < Width; x++ {
+			color := *gfx.BackgroundColor
+			if fromX, fromY := x-dx, y-dy; fromX >= 0 && fromY >= 0 && fromX < Width && fromY < Height {
+				color = video.Get(fromY*Width + fromX)
 			}
+			gfx.VideoMemory.Set(y*Width+x, color)
 		}
-	} else if dx < 0 {
-		for y := 0; y < Height; y++ {
-			offset := y * Width
-			copy(gfx.VideoMemory[offset:], gfx.VideoMemory[offset-dx:offset+Width])
-		}
-		for y := 0; y < Height; y++ {
-			for x := 0; x < -dx; x++ {
-				gfx.VideoMemory[y*Width+Width-1-x] = byte(gfx.BackgroundColor)
+	}
+	return nil
+}
+
+// scrollText moves the text and color memory by dx, dy chars
+func (gfx *Gfx) scrollText(dx, dy int) {
+	const columns, rows = Width / 8, Height / 8
+	text, colors := TextMemory(append([]byte{}, gfx.TextMemory...)), append([]uint8{}, gfx.ColorMemory...)
+	for y := 0; y < rows; y++ {
+		for x := 0; x < columns; x++ {
+			fromX, fromY := x-dx, y-dy
+			if fromX >= 0 && fromY >= 0 && fromX < columns && fromY < rows {
+				gfx.TextMemory.Set(y*columns+x, text.Get(fromY*columns+fromX))
+				gfx.ColorMemory[y*columns+x] = colors[fromY*columns+fromX]
+			} else {
+				gfx.TextMemory.Set(y*columns+x, 0)
+				gfx.ColorMemory[y*columns+x] = gfx.blankCell()
 			}
 		}
 	}
+}
 
-	// todo: also move TextMemory around
-
+func (gfx *Gfx) ClearVideo() error {
+	gfx.VideoMemory.Fill(*gfx.BackgroundColor)
+	for i := 0; i < gfx.TextMemory.Len(); i++ {
+		gfx.TextMemory.Set(i, 0)
+		gfx.ColorMemory[i] = gfx.blankCell()
+	}
 	return nil
 }
 
-func (gfx *Gfx) ClearVideo() error {
-	for i := range gfx.VideoMemory {
-		gfx.VideoMemory[i] = byte(gfx.BackgroundColor)
+// ScrollOffset is how far the renderer scrolls the display, in 320x200 pixels
+func (gfx *Gfx) ScrollOffset() (int, int) {
+	memory := gfx.Memory
+	x := int(memory[ScrollAddress]) | int(memory[ScrollAddress+1])<<8
+	y := int(memory[ScrollAddress+2]) | int(memory[ScrollAddress+3])<<8
+	return x % Width, y % Height
+}
+
+// SetScrollOffset makes the renderer show the display moved left by x and up by y pixels, wrapping around.
+// Unlike Scroll(), the memory doesn't change.
+func (gfx *Gfx) SetScrollOffset(x, y int) {
+	x, y = (x%Width+Width)%Width, (y%Height+Height)%Height
+	memory := gfx.Memory
+	memory[ScrollAddress], memory[ScrollAddress+1] = byte(x), byte(x>>8)
+	memory[ScrollAddress+2], memory[ScrollAddress+3] = byte(y), byte(y>>8)
+}
+
+// rgb is the red, green and blue of a color in the palette
+func (gfx *Gfx) rgb(color uint8) []uint8 {
+	index := int(color&0x0f) * 3
+	return gfx.Colors[index : index+3]
+}
+
+// drawScanline draws the line of the frame at y on the display (negative in the top border): the border, and the display's
+// pixels in the middle. It uses the video mode, palette and scroll offset as they are now, so they can change from line to line.
+func (gfx *Gfx) drawScanline(y int) {
+	border := gfx.Render.Border
+	frameWidth, _ := gfx.Render.FrameSize()
+	line := y + border.Height
+	pixels := gfx.frame[line*frameWidth*3 : (line+1)*frameWidth*3]
+	borderColor := gfx.rgb(*gfx.BorderColor)
+	for x := 0; x < frameWidth; x++ {
+		copy(pixels[x*3:x*3+3], borderColor)
 	}
-	for i := range gfx.TextMemory {
-		gfx.TextMemory[i] = 0
+	if y < 0 || y >= Height {
+		return
+	}
+	scrollX, scrollY := gfx.ScrollOffset()
+	fromY := (y + scrollY) % Height
+	for x := 0; x < Width; x++ {
+		fromX := (x + scrollX) % Width
+		var color uint8
+		if gfx.VideoMode == GfxTextMode {
+			color = gfx.textPixel(fromX, fromY)
+		} else {
+			// multicolor pixels are stored twice, so they're drawn like hires ones
+			color = gfx.VideoMemory.Get(fromY*Width + fromX)
+		}
+		copy(pixels[(border.Width+x)*3:(border.Width+x)*3+3], gfx.rgb(color))
 	}
-	return nil
 }
 
+// UpdateVideo draws a frame, a line at a time, and gives it to the renderer
 func (gfx *Gfx) UpdateVideo() error {
-	gfx.Render.Lock.Lock()
-	for index, colorIndex := range gfx.VideoMemory {
-		gfx.Render.PixelMemory[index*3] = gfx.Colors[colorIndex*3]
-		gfx.Render.PixelMemory[index*3+1] = gfx.Colors[colorIndex*3+1]
-		gfx.Render.PixelMemory[index*3+2] = gfx.Colors[colorIndex*3+2]
+	border := gfx.Render.Border
+	for y := -border.Height; y < Height+border.Height; y++ {
+		if gfx.OnScanline != nil {
+			if err := gfx.OnScanline(y); err != nil {
+				return err
+			}
+		}
+		gfx.drawScanline(y)
 	}
+	gfx.Render.Lock.Lock()
+	copy(gfx.Render.PixelMemory, gfx.frame)
+	events := gfx.Render.nextFrame()
 	gfx.Render.Lock.Unlock()
+	gfx.Clock.Frame()
+	for _, event := range events {
+		gfx.deliverEvent(event)
+	}
 	// runtime.Gosched()
 	return nil
 }
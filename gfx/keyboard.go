@@ -0,0 +1,81 @@
+package gfx
+
+import (
+	"sync"
+
+	"github.com/go-gl/glfw/v3.2/glfw"
+)
+
+// how many key events are kept before the oldest ones are dropped
+const keyQueueSize = 256
+
+// KeyEvent is a key pressed, released or repeated (held down)
+type KeyEvent struct {
+	Key    glfw.Key
+	Action glfw.Action
+	Mods   glfw.ModifierKey
+}
+
+// Keyboard keeps the state of the keys and a queue of key events, so taps between two frames are not lost
+type Keyboard struct {
+	lock sync.Mutex
+	down map[glfw.Key]bool
+	// how many times each key was pressed
+	presses map[glfw.Key]int
+	events  []KeyEvent
+}
+
+func NewKeyboard() *Keyboard {
+	return &Keyboard{
+		down:    map[glfw.Key]bool{},
+		presses: map[glfw.Key]int{},
+		events:  []KeyEvent{},
+	}
+}
+
+// KeyEvent records a key event. It's called by the renderer, but can also be used to simulate key presses.
+func (keyboard *Keyboard) KeyEvent(key glfw.Key, action glfw.Action, mods glfw.ModifierKey) {
+	keyboard.lock.Lock()
+	keyboard.down[key] = action == glfw.Repeat || action == glfw.Press
+	if action == glfw.Press {
+		keyboard.presses[key]++
+	}
+	if len(keyboard.events) >= keyQueueSize {
+		keyboard.events = keyboard.events[1:]
+	}
+	keyboard.events = append(keyboard.events, KeyEvent{Key: key, Action: action, Mods: mods})
+	keyboard.lock.Unlock()
+}
+
+// IsDown is true while the key is held down
+func (keyboard *Keyboard) IsDown(key glfw.Key) bool {
+	keyboard.lock.Lock()
+	defer keyboard.lock.Unlock()
+	return keyboard.down[key]
+}
+
+// Presses is how many times the key was pressed so far
+func (keyboard *Keyboard) Presses(key glfw.Key) int {
+	keyboard.lock.Lock()
+	defer keyboard.lock.Unlock()
+	return keyboard.presses[key]
+}
+
+// NextKey removes the oldest event from the queue. It's false if the queue is empty.
+func (keyboard *Keyboard) NextKey() (KeyEvent, bool) {
+	keyboard.lock.Lock()
+	defer keyboard.lock.Unlock()
+	if len(keyboard.events) == 0 {
+		return KeyEvent{}, false
+	}
+	event := keyboard.events[0]
+	keyboard.events = keyboard.events[1:]
+	return event, true
+}
+
+// ClearKeys empties the event queue
+func (keyboard *Keyboard) ClearKeys() {
+	keyboard.lock.Lock()
+	keyboard.events = []KeyEvent{}
+	keyboard.lock.Unlock()
+}
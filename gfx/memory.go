@@ -0,0 +1,158 @@
+package gfx
+
+//go:generate go run memorymap_gen.go -o ../docs/memory-map.md
+
+import (
+	"fmt"
+	"io"
+	"unsafe"
+)
+
+// The machine has a flat 64K memory. The video, text, color and font memory, the palette, the background and border
+// color, the scroll offset and the sprite registers are all in it, and peek() and poke() can read and change any of
+// it. MemoryMap documents where things are.
+
+const MemorySize = 0x10000
+
+const (
+	VideoAddress      = 0x0000
+	TextAddress       = 0x8000
+	ColorAddress      = 0x8800
+	FontAddress       = 0x9000
+	PaletteAddress    = 0xa000
+	BackgroundAddress = 0xa030
+	BorderAddress     = 0xa031
+	ScrollAddress     = 0xa032
+	SpriteAddress     = 0xa040
+	FreeAddress       = 0xa100
+)
+
+// the sprite registers: SpriteCount sprites of SpriteSize bytes each
+const (
+	SpriteCount = 8
+	SpriteSize  = 8
+)
+
+// MemoryRegion is a part of the memory with a purpose
+type MemoryRegion struct {
+	Name        string
+	Address     int
+	Size        int
+	Description string
+}
+
+// MemoryMap lists the regions of the memory, in address order
+var MemoryMap = []MemoryRegion{
+	{"Video", VideoAddress, Width * Height / 2, "The pixels, 320x200, 2 per byte: the low 4 bits are the color of the left pixel, the high 4 bits the right one. " +
+		"In multicolor mode each pixel is stored twice. In text mode it isn't shown: the screen is drawn from text and color memory."},
+	{"Text", TextAddress, Width / 8 * Height / 8 * 2, "The chars of the 40x25 text screen, a row at a time, 2 bytes per char: the glyph's code, low byte first."},
+	{"Color", ColorAddress, Width / 8 * Height / 8, "The colors of the chars in text memory, 1 byte per char: the low 4 bits are the foreground, the high 4 bits the background."},
+	{"Font", FontAddress, GlyphCount * 8, "The 512 glyphs, 8 bytes each: a byte per row, bit 0 is the leftmost pixel."},
+	{"Palette", PaletteAddress, 16 * 3, "The red, green and blue of the 16 colors."},
+	{"Background", BackgroundAddress, 1, "The background color."},
+	{"Border", BorderAddress, 1, "The color of the border around the display."},
+	{"Scroll", ScrollAddress, 4, "How far the display is scrolled when it's drawn, in 320x200 pixels: x, then y, 2 bytes each, low byte first. The display wraps around."},
+	{"Sprites", SpriteAddress, SpriteCount * SpriteSize, "Reserved for the registers of 8 sprites, 8 bytes each. The video card doesn't draw sprites yet, so their layout isn't fixed: don't keep data here."},
+	{"Free", FreeAddress, MemorySize - FreeAddress, "Not used by the machine: programs can keep their own data here."},
+}
+
+// Memory is the machine's memory
+type Memory [MemorySize]byte
+
+// Peek reads a byte
+func (memory *Memory) Peek(address int) (uint8, error) {
+	if address < 0 || address >= MemorySize {
+		return 0, fmt.Errorf("no address %d, the memory is 0-%d", address, MemorySize-1)
+	}
+	return memory[address], nil
+}
+
+// Poke writes a byte
+func (memory *Memory) Poke(address int, value int) error {
+	if address < 0 || address >= MemorySize {
+		return fmt.Errorf("no address %d, the memory is 0-%d", address, MemorySize-1)
+	}
+	if value < 0 || value > 255 {
+		return fmt.Errorf("a byte is 0-255, not %d", value)
+	}
+	memory[address] = uint8(value)
+	return nil
+}
+
+// VideoMemory is the view of the pixels in the memory, indexed by y*Width+x
+type VideoMemory []byte
+
+// Get returns the color of a pixel
+func (video VideoMemory) Get(index int) uint8 {
+	if index%2 == 0 {
+		return video[index/2] & 0x0f
+	}
+	return video[index/2] >> 4
+}
+
+// Set changes the color of a pixel
+func (video VideoMemory) Set(index int, color uint8) {
+	if index%2 == 0 {
+		video[index/2] = video[index/2]&0xf0 | color&0x0f
+	} else {
+		video[index/2] = video[index/2]&0x0f | color<<4
+	}
+}
+
+// Fill sets all pixels to color
+func (video VideoMemory) Fill(color uint8) {
+	for i := range video {
+		video[i] = color&0x0f | color<<4
+	}
+}
+
+// TextMemory is the view of the chars in the memory, indexed by y*40+x
+type TextMemory []byte
+
+// Get returns the glyph code of a char
+func (text TextMemory) Get(index int) rune {
+	return rune(text[index*2]) | rune(text[index*2+1])<<8
+}
+
+// Set changes the glyph code of a char
+func (text TextMemory) Set(index int, ch rune) {
+	text[index*2] = byte(ch)
+	text[index*2+1] = byte(ch >> 8)
+}
+
+// Len is the number of chars
+func (text TextMemory) Len() int {
+	return len(text) / 2
+}
+
+// mapMemory points the video card's fields to their place in the memory
+func (gfx *Gfx) mapMemory() {
+	memory := gfx.Memory
+	gfx.VideoMemory = VideoMemory(memory[VideoAddress : VideoAddress+Width*Height/2])
+	gfx.TextMemory = TextMemory(memory[TextAddress : TextAddress+Width/8*Height/8*2])
+	gfx.ColorMemory = memory[ColorAddress : ColorAddress+Width/8*Height/8]
+	// byte arrays have no alignment, so they can be anywhere in the memory
+	gfx.Font = (*[GlyphCount][8]uint8)(unsafe.Pointer(&memory[FontAddress]))
+	gfx.Colors = (*[16 * 3]uint8)(unsafe.Pointer(&memory[PaletteAddress]))
+	gfx.BackgroundColor = &memory[BackgroundAddress]
+	gfx.BorderColor = &memory[BorderAddress]
+}
+
+// WriteMemoryMap writes the memory map as a markdown document
+func WriteMemoryMap(w io.Writer) error {
+	_, err := fmt.Fprintf(w, "# The benji4000 memory map\n\n"+
+		"This file is generated from gfx/memory.go by `go generate ./gfx`, don't edit it.\n\n"+
+		"Use `peek(address)` and `poke(address, value)` to read and write the memory from bscript.\n\n"+
+		"| Address | Size | Name | Description |\n"+
+		"|---|---|---|---|\n")
+	if err != nil {
+		return err
+	}
+	for _, region := range MemoryMap {
+		_, err = fmt.Fprintf(w, "| `$%04X-$%04X` | %d | %s | %s |\n", region.Address, region.Address+region.Size-1, region.Size, region.Name, region.Description)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
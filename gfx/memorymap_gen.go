@@ -0,0 +1,29 @@
+//go:build ignore
+// +build ignore
+
+// memorymap_gen writes the memory map document, see go:generate in memory.go
+package main
+
+import (
+	"flag"
+	"log"
+	"os"
+
+	"github.com/uzudil/benji4000/gfx"
+)
+
+func main() {
+	out := flag.String("o", "memory-map.md", "the file to write")
+	flag.Parse()
+
+	f, err := os.Create(*out)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if err := gfx.WriteMemoryMap(f); err != nil {
+		log.Fatal(err)
+	}
+	if err := f.Close(); err != nil {
+		log.Fatal(err)
+	}
+}
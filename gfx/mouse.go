@@ -0,0 +1,148 @@
+package gfx
+
+import (
+	"sync"
+
+	"github.com/go-gl/glfw/v3.2/glfw"
+)
+
+// how many mouse events are kept before the oldest ones are dropped
+const mouseQueueSize = 256
+
+// the kinds of mouse events
+const (
+	MouseMove = iota
+	MousePress
+	MouseRelease
+	MouseWheel
+)
+
+// MouseEvent is the mouse moving, a button pressed or released, or the wheel turning.
+// X and Y are where the mouse was, in 320x200 screen pixels.
+type MouseEvent struct {
+	Type   int
+	X, Y   int
+	Button glfw.MouseButton
+	// how far the wheel turned
+	DX, DY float64
+}
+
+// Mouse keeps the position and buttons of the mouse and a queue of mouse events
+type Mouse struct {
+	lock   sync.Mutex
+	x, y   int
+	down   map[glfw.MouseButton]bool
+	events []MouseEvent
+}
+
+func NewMouse() *Mouse {
+	return &Mouse{
+		down:   map[glfw.MouseButton]bool{},
+		events: []MouseEvent{},
+	}
+}
+
+// WindowToScreen maps a position in a window of the given size to 320x200 screen pixels. The window shows the border too:
+// a position on the border is mapped to the nearest edge of the screen.
+func (render *Render) WindowToScreen(x, y float64, width, height int) (int, int) {
+	if width <= 0 || height <= 0 {
+		return 0, 0
+	}
+	frameWidth, frameHeight := render.FrameSize()
+	sx := int(x*float64(frameWidth)/float64(width)) - render.Border.Width
+	sy := int(y*float64(frameHeight)/float64(height)) - render.Border.Height
+	return clamp(sx, 0, Width-1), clamp(sy, 0, Height-1)
+}
+
+func clamp(n, min, max int) int {
+	if n < min {
+		return min
+	}
+	if n > max {
+		return max
+	}
+	return n
+}
+
+// the lock is held by the caller
+func (mouse *Mouse) queue(event MouseEvent) {
+	if event.Type == MouseMove && len(mouse.events) > 0 && mouse.events[len(mouse.events)-1].Type == MouseMove {
+		// only the last position of a move matters
+		mouse.events[len(mouse.events)-1] = event
+		return
+	}
+	if len(mouse.events) >= mouseQueueSize {
+		mouse.events = mouse.events[1:]
+	}
+	mouse.events = append(mouse.events, event)
+}
+
+// The methods below are called by the renderer, but can also be used to simulate the mouse. Positions are in 320x200 screen pixels.
+
+// Move moves the mouse to x, y
+func (mouse *Mouse) Move(x, y int) {
+	mouse.lock.Lock()
+	defer mouse.lock.Unlock()
+	mouse.x, mouse.y = clamp(x, 0, Width-1), clamp(y, 0, Height-1)
+	mouse.queue(MouseEvent{Type: MouseMove, X: mouse.x, Y: mouse.y})
+}
+
+// Button presses or releases a mouse button
+func (mouse *Mouse) Button(button glfw.MouseButton, pressed bool) {
+	mouse.lock.Lock()
+	defer mouse.lock.Unlock()
+	mouse.down[button] = pressed
+	event := MouseEvent{Type: MouseRelease, X: mouse.x, Y: mouse.y, Button: button}
+	if pressed {
+		event.Type = MousePress
+	}
+	mouse.queue(event)
+}
+
+// Wheel turns the mouse wheel
+func (mouse *Mouse) Wheel(dx, dy float64) {
+	mouse.lock.Lock()
+	defer mouse.lock.Unlock()
+	mouse.queue(MouseEvent{Type: MouseWheel, X: mouse.x, Y: mouse.y, DX: dx, DY: dy})
+}
+
+// Position is where the mouse is, in 320x200 screen pixels
+func (mouse *Mouse) Position() (int, int) {
+	mouse.lock.Lock()
+	defer mouse.lock.Unlock()
+	return mouse.x, mouse.y
+}
+
+// IsDown is true while the button is held down
+func (mouse *Mouse) IsDown(button glfw.MouseButton) bool {
+	mouse.lock.Lock()
+	defer mouse.lock.Unlock()
+	return mouse.down[button]
+}
+
+// NextMouse removes the oldest event from the queue. It's false if the queue is empty.
+func (mouse *Mouse) NextMouse() (MouseEvent, bool) {
+	mouse.lock.Lock()
+	defer mouse.lock.Unlock()
+	if len(mouse.events) == 0 {
+		return MouseEvent{}, false
+	}
+	event := mouse.events[0]
+	mouse.events = mouse.events[1:]
+	return event, true
+}
+
+// ClearMouse empties the event queue
+func (mouse *Mouse) ClearMouse() {
+	mouse.lock.Lock()
+	mouse.events = []MouseEvent{}
+	mouse.lock.Unlock()
+}
+
+// MouseX maps a screen pixel x to the current video mode: in multicolor mode pixels are double wide
+func (gfx *Gfx) MouseX(x int) int {
+	if gfx.VideoMode == GfxMultiColorMode {
+		return x / 2
+	}
+	return x
+}
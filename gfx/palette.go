@@ -0,0 +1,145 @@
+package gfx
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// C64 colors :-)
+var defaultColors = [16 * 3]uint8{
+	0x00, 0x00, 0x00,
+	0xff, 0xff, 0xff,
+	0x88, 0x20, 0x00,
+	0x68, 0xd0, 0xa8,
+	0xa8, 0x38, 0xa0,
+	0x50, 0xb8, 0x18,
+	0x18, 0x10, 0x90,
+	0xf0, 0xe8, 0x58,
+	0xa0, 0x48, 0x00,
+	0x47, 0x2b, 0x1b,
+	0xc8, 0x78, 0x70,
+	0x48, 0x48, 0x48,
+	0x80, 0x80, 0x80,
+	0x98, 0xff, 0x98,
+	0x50, 0x90, 0xd0,
+	0xb8, 0xb8, 0xb8,
+}
+
+// Palettes are the named palettes setPalette() can switch to. The colors are in the order of the COLOR_ constants.
+var Palettes = map[string][16 * 3]uint8{
+	"default": defaultColors,
+	// the C64 colors as measured by Pepto
+	"pepto": {
+		0x00, 0x00, 0x00,
+		0xff, 0xff, 0xff,
+		0x68, 0x37, 0x2b,
+		0x70, 0xa4, 0xb2,
+		0x6f, 0x3d, 0x86,
+		0x58, 0x8d, 0x43,
+		0x35, 0x28, 0x79,
+		0xb8, 0xc7, 0x6f,
+		0x6f, 0x4f, 0x25,
+		0x43, 0x39, 0x00,
+		0x9a, 0x67, 0x59,
+		0x44, 0x44, 0x44,
+		0x6c, 0x6c, 0x6c,
+		0x9a, 0xd2, 0x84,
+		0x6c, 0x5e, 0xb5,
+		0x95, 0x95, 0x95,
+	},
+	// the 16 CGA colors. CGA has no dark brown, tan or mid gray: they are light magenta, light red and light cyan instead.
+	"cga": {
+		0x00, 0x00, 0x00,
+		0xff, 0xff, 0xff,
+		0xaa, 0x00, 0x00,
+		0x00, 0xaa, 0xaa,
+		0xaa, 0x00, 0xaa,
+		0x00, 0xaa, 0x00,
+		0x00, 0x00, 0xaa,
+		0xff, 0xff, 0x55,
+		0xaa, 0x55, 0x00,
+		0xff, 0x55, 0xff,
+		0xff, 0x55, 0x55,
+		0x55, 0x55, 0x55,
+		0x55, 0xff, 0xff,
+		0x55, 0xff, 0x55,
+		0x55, 0x55, 0xff,
+		0xaa, 0xaa, 0xaa,
+	},
+	// the default colors as shades of gray, by their brightness
+	"grayscale": grayscale(defaultColors),
+}
+
+func grayscale(colors [16 * 3]uint8) [16 * 3]uint8 {
+	for i := 0; i < len(colors); i += 3 {
+		gray := uint8(0.299*float64(colors[i]) + 0.587*float64(colors[i+1]) + 0.114*float64(colors[i+2]))
+		colors[i], colors[i+1], colors[i+2] = gray, gray, gray
+	}
+	return colors
+}
+
+func checkColorIndex(index int) error {
+	if index < 0 || index >= 16 {
+		return fmt.Errorf("no color %d, the colors are 0-15", index)
+	}
+	return nil
+}
+
+// SetPaletteColor changes the red, green and blue of a color
+func (gfx *Gfx) SetPaletteColor(index int, r, g, b uint8) error {
+	if err := checkColorIndex(index); err != nil {
+		return err
+	}
+	gfx.Colors[index*3], gfx.Colors[index*3+1], gfx.Colors[index*3+2] = r, g, b
+	return nil
+}
+
+// GetPaletteColor returns the red, green and blue of a color
+func (gfx *Gfx) GetPaletteColor(index int) (uint8, uint8, uint8, error) {
+	if err := checkColorIndex(index); err != nil {
+		return 0, 0, 0, err
+	}
+	return gfx.Colors[index*3], gfx.Colors[index*3+1], gfx.Colors[index*3+2], nil
+}
+
+// SetPalette switches to one of the Palettes
+func (gfx *Gfx) SetPalette(name string) error {
+	colors, ok := Palettes[strings.ToLower(name)]
+	if !ok {
+		names := []string{}
+		for name := range Palettes {
+			names = append(names, name)
+		}
+		sort.Strings(names)
+		return fmt.Errorf("no palette %q, the palettes are: %s", name, strings.Join(names, ", "))
+	}
+	*gfx.Colors = colors
+	return nil
+}
+
+// ResetPalette switches back to the default palette
+func (gfx *Gfx) ResetPalette() {
+	*gfx.Colors = defaultColors
+}
+
+// CyclePalette rotates the colors from..to (inclusive) by step places: with step 1, color from gets the color of to,
+// and every other color the one before it. A negative step rotates the other way.
+func (gfx *Gfx) CyclePalette(from, to, step int) error {
+	if err := checkColorIndex(from); err != nil {
+		return err
+	}
+	if err := checkColorIndex(to); err != nil {
+		return err
+	}
+	if from > to {
+		return fmt.Errorf("can't cycle colors %d-%d, the first color should come before the last", from, to)
+	}
+	count := to - from + 1
+	colors := *gfx.Colors
+	for index := from; index <= to; index++ {
+		source := from + ((index-from-step)%count+count)%count
+		copy(gfx.Colors[index*3:index*3+3], colors[source*3:source*3+3])
+	}
+	return nil
+}
@@ -0,0 +1,146 @@
+package gfx
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/go-gl/glfw/v3.2/glfw"
+)
+
+// A recording is the input of a run: the random seed, the step of the clock and every key and char event, with the
+// frame it arrived in. The window's events are held until the end of a frame (see nextFrame()), both when recording
+// and when replaying, so replaying feeds the same events to the program at the same point and a bug that depends on
+// exact key timing happens again. To make the runs identical, both use a SteppedClock with the recorded step.
+//
+// The file has a line for the seed and the step, then one line per event:
+//
+//	seed 1588532049
+//	step 0.016666666666666666	(seconds per frame)
+//	key <frame> <key> <action> <mods>
+//	char <frame> <char>	(a char typed while input() waits, 9 is backspace)
+//	stop <frame>	(enter pressed while input() waits)
+
+// InputEvent is a recorded key or char event
+type InputEvent struct {
+	Frame int
+	// "key", "char" or "stop"
+	Type   string
+	Key    glfw.Key
+	Action glfw.Action
+	Mods   glfw.ModifierKey
+	Char   rune
+}
+
+func (event InputEvent) String() string {
+	switch event.Type {
+	case "key":
+		return fmt.Sprintf("key %d %d %d %d", event.Frame, event.Key, event.Action, event.Mods)
+	case "char":
+		return fmt.Sprintf("char %d %d", event.Frame, event.Char)
+	}
+	return fmt.Sprintf("%s %d", event.Type, event.Frame)
+}
+
+// Record starts writing the input events to w. step is how far the SteppedClock moves per frame, in seconds.
+func (render *Render) Record(w io.Writer, seed int64, step float64) error {
+	if _, err := fmt.Fprintf(w, "seed %d\nstep %v\n", seed, step); err != nil {
+		return err
+	}
+	render.Lock.Lock()
+	render.recording = w
+	render.Lock.Unlock()
+	return nil
+}
+
+// Replay reads a recording and returns its random seed and clock step (0 if it has none). From now on the events come
+// from the recording, instead of the window.
+func (render *Render) Replay(r io.Reader) (seed int64, step float64, err error) {
+	events := []InputEvent{}
+	scanner := bufio.NewScanner(r)
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" {
+			continue
+		}
+		var event InputEvent
+		switch strings.Fields(text)[0] {
+		case "seed":
+			_, err = fmt.Sscanf(text, "seed %d", &seed)
+		case "step":
+			_, err = fmt.Sscanf(text, "step %g", &step)
+		case "key":
+			_, err = fmt.Sscanf(text, "key %d %d %d %d", &event.Frame, &event.Key, &event.Action, &event.Mods)
+		case "char":
+			_, err = fmt.Sscanf(text, "char %d %d", &event.Frame, &event.Char)
+		case "stop":
+			_, err = fmt.Sscanf(text, "stop %d", &event.Frame)
+		default:
+			err = fmt.Errorf("unknown event")
+		}
+		if err != nil {
+			return 0, 0, fmt.Errorf("line %d of the recording: %v", line, err)
+		}
+		if event.Type = strings.Fields(text)[0]; event.Type != "seed" && event.Type != "step" {
+			events = append(events, event)
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return 0, 0, err
+	}
+	render.Lock.Lock()
+	render.replaying = true
+	render.replay = events
+	render.pending = nil
+	render.Lock.Unlock()
+	return seed, step, nil
+}
+
+// queue holds an event from the window until the end of the frame. While replaying, the window's events are ignored.
+func (render *Render) queue(event InputEvent) {
+	render.Lock.Lock()
+	defer render.Lock.Unlock()
+	if !render.replaying {
+		render.pending = append(render.pending, event)
+	}
+}
+
+// nextFrame counts a frame and returns the events that arrived in it: the replayed ones, or the window's (which are
+// recorded, if there is a recording). The lock is held by the caller.
+func (render *Render) nextFrame() []InputEvent {
+	render.Frame++
+	if render.replaying {
+		events := []InputEvent{}
+		for len(render.replay) > 0 && render.replay[0].Frame <= render.Frame {
+			events = append(events, render.replay[0])
+			render.replay = render.replay[1:]
+		}
+		return events
+	}
+	events := render.pending
+	render.pending = nil
+	for index := range events {
+		events[index].Frame = render.Frame
+		if render.recording == nil {
+			continue
+		}
+		if _, err := fmt.Fprintln(render.recording, events[index]); err != nil {
+			fmt.Printf("Can't record input: %v\n", err)
+			render.recording = nil
+		}
+	}
+	return events
+}
+
+// deliverEvent gives an event from the window or the recording to the keyboard or input()
+func (gfx *Gfx) deliverEvent(event InputEvent) {
+	switch event.Type {
+	case "key":
+		gfx.Keyboard.KeyEvent(event.Key, event.Action, event.Mods)
+	case "char":
+		gfx.Render.CharInput <- event.Char
+	case "stop":
+		gfx.Render.StopInput <- 1
+	}
+}
@@ -2,10 +2,14 @@ package gfx
 
 import (
 	"fmt"
+	"image"
+	"image/png"
+	"io"
 	"log"
 	"runtime"
 	"strings"
 	"sync"
+	"time"
 
 	"github.com/go-gl/gl/v4.1-core/gl"
 	"github.com/go-gl/glfw/v3.2/glfw"
@@ -47,9 +51,7 @@ const (
 )
 
 var (
-	KeyLock = sync.Mutex{}
-	KeyDown = map[glfw.Key]bool{}
-	screen  = []float32{
+	screen = []float32{
 		// xyz		color		texture coords
 		-1, 1, 0, 1, 1, 1, 0, 0,
 		-1, -1, 0, 1, 1, 1, 0, 1,
@@ -61,34 +63,44 @@ var (
 )
 
 type Render struct {
-	// the video memory
-	PixelMemory [Width * Height * 3]byte
+	// the video memory: the whole frame, the display and the border around it, 3 bytes (red, green, blue) per pixel
+	PixelMemory []byte
 	Lock        sync.Mutex
 	Window      *glfw.Window
 	Program     uint32
 	Vao         uint32
-	// the desired framerate of the bscript code. This is how often the video texture is updated
-	Fps float64
+	// the desired framerate of the bscript code. This is how often the video texture is updated.
+	// MainLoop reads it, so use GetFps() and SetFps().
+	fps float64
+	// the size of the border around the display, it doesn't change
+	Border Border
 
 	// input mode channels
 	InputMode  bool
 	StartInput chan int
 	StopInput  chan int
 	CharInput  chan rune
+
+	// how many frames were shown: the number of UpdateVideo() calls
+	Frame int
+	// true while MainLoop runs
+	running bool
+	// MainLoop signals on it when it shows a frame, see WaitFrame()
+	frameShown chan bool
+	// where the input is recorded, see Record()
+	recording io.Writer
+	// true if the input comes from a recording, see Replay()
+	replaying bool
+	// the recorded events not replayed yet
+	replay []InputEvent
+	// the window's events in this frame
+	pending []InputEvent
 }
 
-func NewRender() *Render {
+func NewRender(border Border, keyboard *Keyboard, mouse *Mouse) *Render {
 	// make sure this happens first
-	render := &Render{
-		PixelMemory: [Width * Height * 3]byte{},
-		Lock:        sync.Mutex{},
-		Fps:         60,
-		InputMode:   false,
-		StartInput:  make(chan int, 100),
-		StopInput:   make(chan int, 100),
-		CharInput:   make(chan rune, 1000),
-	}
-	render.Window = initGlfw(render)
+	render := newRender(border)
+	render.Window = initGlfw(render, keyboard, mouse)
 	render.Program = initOpenGL()
 	render.Vao = makeVao()
 
@@ -97,8 +109,24 @@ func NewRender() *Render {
 	return render
 }
 
-// initGlfw initializes glfw and returns a Window to use.
-func initGlfw(render *Render) *glfw.Window {
+// newRender creates a renderer without a window
+func newRender(border Border) *Render {
+	frameWidth, frameHeight := Width+2*border.Width, Height+2*border.Height
+	return &Render{
+		PixelMemory: make([]byte, frameWidth*frameHeight*3),
+		Border:      border,
+		Lock:        sync.Mutex{},
+		fps:         60,
+		InputMode:   false,
+		StartInput:  make(chan int, 100),
+		StopInput:   make(chan int, 100),
+		CharInput:   make(chan rune, 1000),
+		frameShown:  make(chan bool, 1),
+	}
+}
+
+// initGlfw initializes glfw and returns a Window to use. Key events go to keyboard, mouse events to mouse.
+func initGlfw(render *Render, keyboard *Keyboard, mouse *Mouse) *glfw.Window {
 	if err := glfw.Init(); err != nil {
 		panic(err)
 	}
@@ -108,32 +136,43 @@ func initGlfw(render *Render) *glfw.Window {
 	glfw.WindowHint(glfw.OpenGLProfile, glfw.OpenGLCoreProfile)
 	glfw.WindowHint(glfw.OpenGLForwardCompatible, glfw.True)
 
-	window, err := glfw.CreateWindow(Width*scale, Height*scale, "Benji4000", nil, nil)
+	frameWidth, frameHeight := render.FrameSize()
+	window, err := glfw.CreateWindow(frameWidth*scale, frameHeight*scale, "Benji4000", nil, nil)
 	if err != nil {
 		panic(err)
 	}
 	window.MakeContextCurrent()
+	// key and char events are delivered at the end of the frame, see nextFrame()
 	window.SetCharCallback(func(w *glfw.Window, char rune) {
 		if render.InputMode {
-			render.CharInput <- char
+			render.queue(InputEvent{Type: "char", Char: char})
 		}
 	})
 	window.SetKeyCallback(func(w *glfw.Window, key glfw.Key, scancode int, action glfw.Action, mods glfw.ModifierKey) {
 		// fmt.Printf("Key pressed: %v, Action=%v, scancode=%d\n", key, action, scancode)
+		// queued before enter stops input(), which clears the keys typed while it waited
+		render.queue(InputEvent{Type: "key", Key: key, Action: action, Mods: mods})
 		if render.InputMode {
 			if action == glfw.Release {
 				if key == glfw.KeyEnter {
-					render.StopInput <- 1
+					render.queue(InputEvent{Type: "stop"})
 					render.InputMode = false
 				} else if key == glfw.KeyBackspace {
-					render.CharInput <- 9
+					render.queue(InputEvent{Type: "char", Char: 9})
 				}
 			}
 		}
-
-		KeyLock.Lock()
-		KeyDown[key] = action == glfw.Repeat || action == glfw.Press
-		KeyLock.Unlock()
+	})
+	window.SetCursorPosCallback(func(w *glfw.Window, x, y float64) {
+		// the window can be resized, so map the position by its current size
+		width, height := w.GetSize()
+		mouse.Move(render.WindowToScreen(x, y, width, height))
+	})
+	window.SetMouseButtonCallback(func(w *glfw.Window, button glfw.MouseButton, action glfw.Action, mods glfw.ModifierKey) {
+		mouse.Button(button, action == glfw.Press)
+	})
+	window.SetScrollCallback(func(w *glfw.Window, dx, dy float64) {
+		mouse.Wheel(dx, dy)
 	})
 
 	return window
@@ -218,8 +257,76 @@ func makeVao() uint32 {
 	return vao
 }
 
-func (render *Render) GetTicks() float64 {
-	return glfw.GetTime()
+// GetFps is the desired frames per second
+func (render *Render) GetFps() float64 {
+	render.Lock.Lock()
+	defer render.Lock.Unlock()
+	return render.fps
+}
+
+// SetFps changes the desired frames per second
+func (render *Render) SetFps(fps float64) {
+	render.Lock.Lock()
+	render.fps = fps
+	render.Lock.Unlock()
+}
+
+// WaitFrame blocks until MainLoop shows the next frame on the screen.
+// Without a MainLoop (eg. in tests) it waits for as long as a frame takes at the frame rate.
+func (render *Render) WaitFrame() {
+	render.Lock.Lock()
+	running, fps := render.running, render.fps
+	render.Lock.Unlock()
+	if !running {
+		if fps > 0 {
+			time.Sleep(time.Duration(float64(time.Second) / fps))
+		}
+		return
+	}
+	// forget a frame shown before the call
+	select {
+	case <-render.frameShown:
+	default:
+	}
+	<-render.frameShown
+}
+
+// FrameSize is the size of the frame: the display and the border around it
+func (render *Render) FrameSize() (int, int) {
+	return Width + 2*render.Border.Width, Height + 2*render.Border.Height
+}
+
+// FramePixel returns the red, green and blue of a pixel of the last frame given to the renderer. x and y are on the
+// display: the border is at negative positions and past the display's edges.
+func (render *Render) FramePixel(x, y int) ([3]uint8, error) {
+	border := render.Border
+	if x < -border.Width || y < -border.Height || x >= Width+border.Width || y >= Height+border.Height {
+		return [3]uint8{}, fmt.Errorf("no pixel at %d,%d, the frame is %d,%d to %d,%d", x, y,
+			-border.Width, -border.Height, Width+border.Width-1, Height+border.Height-1)
+	}
+	frameWidth, _ := render.FrameSize()
+	index := ((y+border.Height)*frameWidth + x + border.Width) * 3
+	render.Lock.Lock()
+	defer render.Lock.Unlock()
+	return [3]uint8{render.PixelMemory[index], render.PixelMemory[index+1], render.PixelMemory[index+2]}, nil
+}
+
+// Screenshot returns the last frame given to the renderer, with its border
+func (render *Render) Screenshot() *image.RGBA {
+	frameWidth, frameHeight := render.FrameSize()
+	img := image.NewRGBA(image.Rect(0, 0, frameWidth, frameHeight))
+	render.Lock.Lock()
+	defer render.Lock.Unlock()
+	for index := 0; index < frameWidth*frameHeight; index++ {
+		copy(img.Pix[index*4:index*4+3], render.PixelMemory[index*3:index*3+3])
+		img.Pix[index*4+3] = 0xff
+	}
+	return img
+}
+
+// SaveScreenshot writes the last frame, with its border, as a PNG
+func (render *Render) SaveScreenshot(w io.Writer) error {
+	return png.Encode(w, render.Screenshot())
 }
 
 // MainLoop is the main rendering loop where the video ram is sent to the screen.
@@ -237,13 +344,18 @@ func (render *Render) MainLoop() {
 	gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_WRAP_T, gl.CLAMP_TO_EDGE)
 	gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_MIN_FILTER, gl.NEAREST)
 	gl.TexParameteri(gl.TEXTURE_2D, gl.TEXTURE_MAG_FILTER, gl.NEAREST)
-	gl.TexImage2D(gl.TEXTURE_2D, 0, gl.RGB, Width, Height, 0, gl.RGB, gl.UNSIGNED_BYTE, nil)
+	frameWidth, frameHeight := render.FrameSize()
+	gl.TexImage2D(gl.TEXTURE_2D, 0, gl.RGB, int32(frameWidth), int32(frameHeight), 0, gl.RGB, gl.UNSIGNED_BYTE, nil)
 	// gl.GenerateMipmap(gl.TEXTURE_2D)
 
 	// bind to shader
 	gl.UseProgram(render.Program)
 	gl.Uniform1i(gl.GetUniformLocation(render.Program, gl.Str("ourTexture\x00")), 0)
 
+	render.Lock.Lock()
+	render.running = true
+	render.Lock.Unlock()
+
 	var lastTime, delta, lastUpdate float64
 	var nbFrames int
 	for !render.Window.ShouldClose() {
@@ -259,14 +371,18 @@ func (render *Render) MainLoop() {
 		// Cap bscript code fps to the desired limit
 		// This can mean that the video memory in gfx is not what is shown on screen...
 		delta = currentTime - lastUpdate
-		if delta > 1.0/render.Fps {
+		if delta > 1.0/render.GetFps() {
 			// make sure the video ram is not being updated in another goroutine
 			render.Lock.Lock()
-			// need to do this so go.Ptr() works. This could be a bug in go: https://github.com/golang/go/issues/14210
-			pixels := render.PixelMemory
-			gl.TexSubImage2D(gl.TEXTURE_2D, 0, 0, 0, Width, Height, gl.RGB, gl.UNSIGNED_BYTE, gl.Ptr(&pixels[0]))
+			gl.TexSubImage2D(gl.TEXTURE_2D, 0, 0, 0, int32(frameWidth), int32(frameHeight), gl.RGB, gl.UNSIGNED_BYTE, gl.Ptr(&render.PixelMemory[0]))
 			render.Lock.Unlock()
 			lastUpdate = currentTime
+
+			// wake up WaitFrame()
+			select {
+			case render.frameShown <- true:
+			default:
+			}
 		}
 
 		// are we in capture input mode?
@@ -15,14 +15,16 @@ const PLAYER_COLOR = COLOR_TAN;
 
 const DROP_SPEED = .8;
 
-# todo: can't place comments inside map literal
 player := {
     "x": 80,
     "y": 190,
+    # -1 walking left, 1 walking right, 0 standing
     "dir": 0,
+    # the times (in getTicks() seconds) when the next turn and step can happen
     "dirchange": 0,
     "switch": 0,
     "move": 0,
+    # non-zero when the player is hit
     "explode": 0,
     "lives": 5,
     "gravity_enabled": true
@@ -80,8 +82,8 @@ def drawClouds() {
         }
         fillCircle(x - 5 + (random() * 10), y1 - 5 + (random() * 10), random() * 10 + 3, color);
         fillCircle(x - 5 + (random() * 10), y2 - 5 + (random() * 10), random() * 10 + 3, color);
-        i := i + 1;
-        x := x + (random() * 10) + 10;
+        i += 1;
+        x += (random() * 10) + 10;
     }
 }
 
@@ -114,9 +116,9 @@ def updateAcidRain(drops) {
                     drops[nextrow][j] := 1;
                 }
             }
-            j := j + 1;
+            j += 1;
         }
-        i := i - 1;
+        i -= 1;
     }
 
     j := 0;
@@ -126,7 +128,7 @@ def updateAcidRain(drops) {
                 drops[0][j] := 1;
             }
         }
-        j := j + 1;
+        j += 1;
     }
 }
 
@@ -144,16 +146,16 @@ def drawAcidRain(drops) {
                 y := (i + 1) * 20 + 60;
                 drawAcidDrop(x, y);
             }
-            j := j + 1;
+            j += 1;
         }
-        i := i + 1;
+        i += 1;
     }
 }
 
 def drawSoldier(index, x, y) {
     if(getTicks() > waiveTimer) {
         waiveTimer := getTicks() + WAIVE_SPEED;
-        waiveIndex := waiveIndex + 1;
+        waiveIndex += 1;
         if(waiveIndex >= len(WAIVE)) {
             waiveIndex := 0;
         }
@@ -167,7 +169,7 @@ def drawSoldier(index, x, y) {
 }
 
 def drawPlayerHealthy() {
-    drawSoldier(0, player["x"], player["y"]);
+    drawSoldier(0, player.x, player.y);
 }
 
 def testCollision(drops) {
@@ -179,16 +181,16 @@ def testCollision(drops) {
             startx := (j + 1) * 20 + 10;
             starty := (i + 1) * 20 + 65;
 
-            if (player["x"] > startx) {
-                distx := player["x"] - startx;
+            if (player.x > startx) {
+                distx := player.x - startx;
             } else {
-                distx := startx - player["x"];
+                distx := startx - player.x;
             }
             if (distx <= 10) {
                 return true;
             }
         }
-        j := j + 1;
+        j += 1;
     }
     return false;
 }
@@ -201,14 +203,14 @@ def drawPlayerExplode() {
         } else {
             color := COLOR_RED;
         }
-        fillCircle(player["x"] - 5 + (random() * 10), player["y"] - 5 + (random() * 10), random() * 10 + 3, color);
-        i := i + 1;
+        fillCircle(player.x - 5 + (random() * 10), player.y - 5 + (random() * 10), random() * 10 + 3, color);
+        i += 1;
     }
 }
 
 def drawPlayer(drops) {
     if (testCollision(drops)) {
-        player["explode"] := 1;
+        player.explode := 1;
         drawPlayerExplode();
         death := true;
         deathTimer := getTicks() + 4;
@@ -230,7 +232,7 @@ def drawGround() {
 }
 
 def drawUI() {
-    drawText(0, 1, COLOR_LIGHT_BLUE, COLOR_DARK_BLUE, "LIFE:" + player["lives"]);
+    drawText(0, 1, COLOR_LIGHT_BLUE, COLOR_DARK_BLUE, "LIFE:" + player.lives);
 }
 
 def drawTitle() {
@@ -278,20 +280,20 @@ def drawDeath() {
 }
 
 def handleInput() {
-    if(player["explode"] > 0) {
+    if(player.explode > 0) {
         # todo: return must always return a value...
         return false;
     }
 
     if(isKeyDown(KeyLeft)) {
         if(turnDir != -1) {
-            player["dirchange"] := 0;
+            player.dirchange := 0;
         }
         turnDir := -1;
     } else {
         if(isKeyDown(KeyRight)) {
             if(turnDir != 1) {
-                player["dirchange"] := 0;
+                player.dirchange := 0;
             }
             turnDir := 1;
         } else {
@@ -299,39 +301,39 @@ def handleInput() {
         }
     }
 
-    if(getTicks() > player["dirchange"]) {
-        if(turnDir = -1 && player["dir"] > -1) {
-            player["dir"] := player["dir"] - 1;
+    if(getTicks() > player.dirchange) {
+        if(turnDir = -1 && player.dir > -1) {
+            player.dir -= 1;
         }
-        if(turnDir = 1 &&  player["dir"] < 1) {
-            player["dir"] := player["dir"] + 1;
+        if(turnDir = 1 &&  player.dir < 1) {
+            player.dir += 1;
         }
-        player["dirchange"] := getTicks() + 0.15;
+        player.dirchange := getTicks() + 0.15;
     }
 }
 
 def movePlayer() {
 
-    if (player["explode"] > 0) {
+    if (player.explode > 0) {
         return false;
     }
 
-    if(player["dir"] != 0 && getTicks() > player["move"]) {
-        player["move"] := getTicks() + SPEED;
+    if(player.dir != 0 && getTicks() > player.move) {
+        player.move := getTicks() + SPEED;
 
         handled := false;
-        if(player["dir"] = 1 && player["x"] < 80) {
-            player["x"] := player["x"] + 1;
+        if(player.dir = 1 && player.x < 80) {
+            player.x += 1;
             handled := true;
         }
-        if(player["dir"] = -1 && player["x"] > 80) {
-            player["x"] := player["x"] - 1;
+        if(player.dir = -1 && player.x > 80) {
+            player.x -= 1;
             handled := true;
         }
 
         if(handled = false) {
-            if(player["x"] < 130 && player["x"] > 20) {
-                player["x"] := player["x"] + player["dir"];
+            if(player.x < 130 && player.x > 20) {
+                player.x += player.dir;
             }
         }
     }    
@@ -14,21 +14,26 @@ const HIT_GROUND = 1;
 const HIT_PAD = 2;
 const PLAYER_COLOR = COLOR_TAN;
 
-# todo: can't place comments inside map literal
 player := {
     "x": 30 * GROUND_STEP, 
     "y": 100,
+    # -1 facing left, 1 facing right, 0 hovering
     "dir": 0,
+    # the rotor animation frame, it's switched at the "switch" time
     "rotor": 0,
     "switch": 0,
+    # the times (in getTicks() seconds) when the next turn, move and fall can happen
     "dirchange": 0,
     "move": 0,
     "moveY": 0,
+    # when the explosion ends, 0 if the player isn't exploding
     "explode": 0,
     "lives": 5,
+    # false once the chopper has landed
     "gravity_enabled": true,
     "fuel": 100,
     "fuelTimer": -1,
+    # soldiers on board, rescued and lost
     "carry": 0,
     "saved": 0,
     "killed": 0
@@ -54,94 +59,94 @@ waiveTimer := 0;
 waiveIndex := 0;
 
 def handleInput() {
-    if(player["explode"] > 0) {
+    if(player.explode > 0) {
         # todo: return must always return a value...
         return false;
     }
     if(isKeyDown(KeyLeft)) {
         if(turnDir != -1) {
-            player["dirchange"] := 0;
+            player.dirchange := 0;
         }
         turnDir := -1;
     } else {
         if(isKeyDown(KeyRight)) {
             if(turnDir != 1) {
-                player["dirchange"] := 0;
+                player.dirchange := 0;
             }
             turnDir := 1;
         } else {
             turnDir := 0;
         }
     }
-    if(isKeyDown(KeyUp) && player["y"] > 10 && player["fuel"] > 0) {
-        player["y"] := player["y"] - SPEED_Y;
+    if(isKeyDown(KeyUp) && player.y > 10 && player.fuel > 0) {
+        player.y -= SPEED_Y;
     }
     if(isKeyDown(KeyDown)) {
-        player["y"] := player["y"] + SPEED_Y;
+        player.y += SPEED_Y;
     }
 
-    if(getTicks() > player["dirchange"]) {
-        if(turnDir = -1 && player["dir"] > -1) {
-            player["dir"] := player["dir"] - 1;
+    if(getTicks() > player.dirchange) {
+        if(turnDir = -1 && player.dir > -1) {
+            player.dir -= 1;
         }
-        if(turnDir = 1 &&  player["dir"] < 1) {
-            player["dir"] := player["dir"] + 1;
+        if(turnDir = 1 &&  player.dir < 1) {
+            player.dir += 1;
         }
-        player["dirchange"] := getTicks() + 0.15;
+        player.dirchange := getTicks() + 0.15;
     }
 }
 
 def movePlayer() {
-    if(player["explode"] = 0 && getTicks() > player["moveY"] && player["gravity_enabled"]) {
-        player["moveY"] := getTicks() + GRAVITY_SPEED;
+    if(player.explode = 0 && getTicks() > player.moveY && player.gravity_enabled) {
+        player.moveY := getTicks() + GRAVITY_SPEED;
 
         # gravity
-        player["y"] := player["y"] + 1;
+        player.y += 1;
     }
 
-    if(getTicks() > player["fuelTimer"]) {
-        if(player["gravity_enabled"]) {
-            if(player["fuel"] > 0) {
-                player["fuel"] := player["fuel"] - 1;
+    if(getTicks() > player.fuelTimer) {
+        if(player.gravity_enabled) {
+            if(player.fuel > 0) {
+                player.fuel -= 1;
             }
-            player["fuelTimer"] := getTicks() + SPEED_FUEL_DOWN;
+            player.fuelTimer := getTicks() + SPEED_FUEL_DOWN;
         }
-        if(player["gravity_enabled"] = false) {
-            if(player["fuel"] < 100) {
-                player["fuel"] := player["fuel"] + 1;
+        if(player.gravity_enabled = false) {
+            if(player.fuel < 100) {
+                player.fuel += 1;
             }
-            player["fuelTimer"] := getTicks() + SPEED_FUEL;
+            player.fuelTimer := getTicks() + SPEED_FUEL;
         }
     }
 
-    if(player["dir"] != 0 && getTicks() > player["move"]) {
-        player["move"] := getTicks() + SPEED;
+    if(player.dir != 0 && getTicks() > player.move) {
+        player.move := getTicks() + SPEED;
 
         handled := false;
-        if(player["dir"] = 1 && player["x"] < 80) {
-            player["x"] := player["x"] + 1;
+        if(player.dir = 1 && player.x < 80) {
+            player.x += 1;
             handled := true;
         }
-        if(player["dir"] = -1 && player["x"] > 80) {
-            player["x"] := player["x"] - 1;
+        if(player.dir = -1 && player.x > 80) {
+            player.x -= 1;
             handled := true;
         }
 
         if(handled = false) {
             if(canScroll()) {
-                scroll(-2 * player["dir"], 0);
-                scrollStep := scrollStep - player["dir"]*2;
+                scroll(-2 * player.dir, 0);
+                scrollStep -= player.dir*2;
                 if(scrollStep >= GROUND_STEP) {
                     scrollStep := 0;
-                    groundIndex := groundIndex - 1;
+                    groundIndex -= 1;
                 }
                 if(scrollStep <= -1) {
                     scrollStep := GROUND_STEP - 2;
-                    groundIndex := groundIndex + 1;
+                    groundIndex += 1;
                 }                
             } else {
-                if(player["x"] < 160 && player["x"] > 0) {
-                    player["x"] := player["x"] + player["dir"];
+                if(player.x < 160 && player.x > 0) {
+                    player.x += player.dir;
                 }
             } 
         }
@@ -149,36 +154,36 @@ def movePlayer() {
 }
 
 def testCollision() {
-    if(player["dir"] = 0) {
-        sx := player["x"] - 5;
-        ex := player["x"] + 5;
+    if(player.dir = 0) {
+        sx := player.x - 5;
+        ex := player.x + 5;
     }
-    if(player["dir"] = 1) {
-        sx := player["x"] - 12;
-        ex := player["x"] + 5;
+    if(player.dir = 1) {
+        sx := player.x - 12;
+        ex := player.x + 5;
     }
-    if(player["dir"] = -1) {
-        sx := player["x"] - 5;
-        ex := player["x"] + 12;
+    if(player.dir = -1) {
+        sx := player.x - 5;
+        ex := player.x + 12;
     }
-    sy := player["y"] - 10;
-    ey := player["y"] + 5;
+    sy := player.y - 10;
+    ey := player.y + 5;
     while(sx < ex) {
         while(sy < ey) {
-            gi := groundIndex + sx / GROUND_STEP;
+            gi := groundIndex + sx // GROUND_STEP;
             if(gi >= 0 && gi < len(ground)) {
-                if(ground[gi]["pad"] > -1 && sy > 200 - ground[gi]["pad"]) {
+                if(ground[gi].pad > -1 && sy > 200 - ground[gi].pad) {
                     return HIT_PAD;
                 } else {
-                    groundHeight := ground[gi]["height"];
+                    groundHeight := ground[gi].height;
                     if(sy > 200 - groundHeight) {
                         return HIT_GROUND;
                     }
                 }
             }
-            sy := sy + GROUND_HEIGHT_STEP;
+            sy += GROUND_HEIGHT_STEP;
         }
-        sx := sx + GROUND_STEP;
+        sx += GROUND_STEP;
     }
     return HIT_NOTHING;
 }
@@ -191,75 +196,75 @@ def drawPlayerExplode() {
         } else {
             color := COLOR_WHITE;
         }
-        fillCircle(player["x"] - 5 + (random() * 10), player["y"] - 5 + (random() * 10), random() * 10 + 3, color);
-        i := i + 1;
+        fillCircle(player.x - 5 + (random() * 10), player.y - 5 + (random() * 10), random() * 10 + 3, color);
+        i += 1;
     }
 }
 
 def drawPlayerHealthy() {
     # todo: use a sprite instead?
-    fillCircle(player["x"], player["y"], 5, PLAYER_COLOR);
-    if(player["dir"] = 0) {
-        fillRect(player["x"]-3, player["y"]-2, player["x"]+3, player["y"], COLOR_WHITE);
+    fillCircle(player.x, player.y, 5, PLAYER_COLOR);
+    if(player.dir = 0) {
+        fillRect(player.x-3, player.y-2, player.x+3, player.y, COLOR_WHITE);
     }
-    if(player["dir"] = 1) {
-        fillRect(player["x"], player["y"]-2, player["x"]+3, player["y"], COLOR_WHITE);
-        fillRect(player["x"] - 12, player["y"] - 5, player["x"], player["y"], PLAYER_COLOR);
-        fillRect(player["x"] - 12, player["y"] - 7, player["x"]-10, player["y"]-5, PLAYER_COLOR);
+    if(player.dir = 1) {
+        fillRect(player.x, player.y-2, player.x+3, player.y, COLOR_WHITE);
+        fillRect(player.x - 12, player.y - 5, player.x, player.y, PLAYER_COLOR);
+        fillRect(player.x - 12, player.y - 7, player.x-10, player.y-5, PLAYER_COLOR);
     }
-    if(player["dir"] = -1) {
-        fillRect(player["x"]-3, player["y"]-2, player["x"], player["y"], COLOR_WHITE);
-        fillRect(player["x"], player["y"] - 5, player["x"]+12, player["y"], PLAYER_COLOR);
-        fillRect(player["x"]+10, player["y"] - 7, player["x"]+12, player["y"]-5, PLAYER_COLOR);
+    if(player.dir = -1) {
+        fillRect(player.x-3, player.y-2, player.x, player.y, COLOR_WHITE);
+        fillRect(player.x, player.y - 5, player.x+12, player.y, PLAYER_COLOR);
+        fillRect(player.x+10, player.y - 7, player.x+12, player.y-5, PLAYER_COLOR);
     }
-    fillRect(player["x"]-1, player["y"]-10, player["x"]+1, player["y"], PLAYER_COLOR);
+    fillRect(player.x-1, player.y-10, player.x+1, player.y, PLAYER_COLOR);
 
     # animate the rotor
-    if(getTicks() > player["switch"]) {
-        if(player["gravity_enabled"]) {
-            player["rotor"] := player["rotor"] + 1;
-            if (player["rotor"] >= len(ROTOR)) {
-                player["rotor"] := 0;
+    if(getTicks() > player.switch) {
+        if(player.gravity_enabled) {
+            player.rotor += 1;
+            if (player.rotor >= len(ROTOR)) {
+                player.rotor := 0;
             }
         } else {
-            player["rotor"] := 0;
+            player.rotor := 0;
         }
-        player["switch"] := getTicks() + 0.025;
+        player.switch := getTicks() + 0.025;
     }
-    fillRect(player["x"]-ROTOR[player["rotor"]], player["y"]-7, player["x"]+ROTOR[player["rotor"]], player["y"]-10, PLAYER_COLOR);
+    fillRect(player.x-ROTOR[player.rotor], player.y-7, player.x+ROTOR[player.rotor], player.y-10, PLAYER_COLOR);
 }
 
 def drawPlayer() {
-    if(player["explode"] > getTicks()) {
+    if(player.explode > getTicks()) {
         drawPlayerExplode();
     } else {        
-        if(player["explode"] > 0) {
+        if(player.explode > 0) {
             # reset player
-            player["lives"] := player["lives"] - 1;
-            player["y"] := 100;
-            player["explode"] := 0;
-            player["fuel"] := 100;
-            player["killed"] := player["killed"] + player["carry"];
-            player["carry"] := 0;
+            player.lives -= 1;
+            player.y := 100;
+            player.explode := 0;
+            player.fuel := 100;
+            player.killed += player.carry;
+            player.carry := 0;
             i := 0; 
             while(i < len(soldiers)) {
                 if(soldiers[i] = -1000) {
                     del soldiers[i];
                 } else {
-                    i := i + 1;
+                    i += 1;
                 }
             }
         } else {
             # collision check
             collision := testCollision();
             if(collision = HIT_GROUND) {
-                player["explode"] := getTicks() + 1.5;
-                player["dir"] := 0;
+                player.explode := getTicks() + 1.5;
+                player.dir := 0;
             }
-            if(collision = HIT_PAD && player["y"] >= 200 - MAX_HEIGHT - 5) {
-                player["gravity_enabled"] := false;
+            if(collision = HIT_PAD && player.y >= 200 - MAX_HEIGHT - 5) {
+                player.gravity_enabled := false;
             } else {
-                player["gravity_enabled"] := true;
+                player.gravity_enabled := true;
             }
         }
         drawPlayerHealthy();
@@ -274,32 +279,32 @@ def initGround() {
         putPad := len(ground) % 300;
         if(putPad >= 25 && putPad < 35) {
             padHeight := h;
-            if(len(ground) > 0 && ground[len(ground) - 1]["pad"] > -1) {
-                padHeight := ground[len(ground) - 1]["pad"];
+            if(len(ground) > 0 && ground[len(ground) - 1].pad > -1) {
+                padHeight := ground[len(ground) - 1].pad;
             }
-            g["pad"] := padHeight;            
-            g["height"] := 0;
+            g.pad := padHeight;            
+            g.height := 0;
         } else {
-            g["pad"] := -1;
+            g.pad := -1;
         }
         ground[len(ground)] := g;
         if(random() > 0.5) {
             if(h < MAX_HEIGHT) {
-                h := h + GROUND_HEIGHT_STEP;
+                h += GROUND_HEIGHT_STEP;
             }
         } else {
             if(h > 4) {
-                h := h - GROUND_HEIGHT_STEP;
+                h -= GROUND_HEIGHT_STEP;
             }
         }
     }    
 }
 
 def canScroll() {
-    if(player["dir"] = 1 && groundIndex >= len(ground) - (160/GROUND_STEP) - 1) {
+    if(player.dir = 1 && groundIndex >= len(ground) - (160/GROUND_STEP) - 1) {
         return false;
     }
-    if(player["dir"] = -1 && groundIndex <= 1) {
+    if(player.dir = -1 && groundIndex <= 1) {
         return false;
     }
     return true;
@@ -308,7 +313,7 @@ def canScroll() {
 def drawSoldier(index, x, y) {
     if(getTicks() > waiveTimer) {
         waiveTimer := getTicks() + WAIVE_SPEED;
-        waiveIndex := waiveIndex + 1;
+        waiveIndex += 1;
         if(waiveIndex >= len(WAIVE)) {
             waiveIndex := 0;
         }
@@ -328,10 +333,10 @@ def drawGround() {
     }
     sx := x;
     while(x < 160) {
-        gi := groundIndex + x/GROUND_STEP;
+        gi := groundIndex + x // GROUND_STEP;
 
-        if(ground[gi]["pad"] > -1) {
-            h := 200 - ground[gi]["pad"];
+        if(ground[gi].pad > -1) {
+            h := 200 - ground[gi].pad;
             if(gi = 25) {
                 # draw the flag
                 drawLine(x - 3, h - 18, x - 3, h, COLOR_LIGHT_GRAY);
@@ -352,10 +357,10 @@ def drawGround() {
             }
             fillRect(x + scrollStep, h, x + scrollStep + GROUND_STEP, 200, COLOR_DARK_GRAY);
         } else {        
-            fillRect(x + scrollStep, 200 - ground[gi]["height"], x + scrollStep + GROUND_STEP, 200, COLOR_GREEN);
+            fillRect(x + scrollStep, 200 - ground[gi].height, x + scrollStep + GROUND_STEP, 200, COLOR_GREEN);
         }
 
-        x := x + GROUND_STEP;
+        x += GROUND_STEP;
     }
     i := 0;
     while(i < len(soldiers)) {
@@ -363,45 +368,45 @@ def drawGround() {
             drawSoldier(
                 i,
                 soldiers[i] - groundIndex * GROUND_STEP, 
-                200 - ground[soldiers[i] / GROUND_STEP]["pad"]
+                200 - ground[soldiers[i] // GROUND_STEP].pad
             );
         }
-        i := i + 1;
+        i += 1;
     }
 }
 
 def moveSoldiers() {
     # move soldiers towards nearby landed chopper
-    if(player["gravity_enabled"] = false && getTicks() > soldierMoveTimer) {
+    if(player.gravity_enabled = false && getTicks() > soldierMoveTimer) {
         i := 0;
         while(i < len(soldiers)) {
             if(soldiers[i] = -1000 && groundIndex < 300) {
                 # exit chopper
                 soldiers[i] := (25 + random() * 10) * GROUND_STEP;
-                player["carry"] := player["carry"] - 1;
-                player["saved"] := player["saved"] + 1;
+                player.carry -= 1;
+                player.saved += 1;
             } else {
                 # need to be saved
-                if(soldiers[i] > 300 && player["carry"] < 4) {
+                if(soldiers[i] > 300 && player.carry < 4) {
                     sx := soldiers[i] - groundIndex * GROUND_STEP;
-                    d := sx - player["x"];
+                    d := sx - player.x;
                     if(abs(d) < 10 * GROUND_STEP) {
                         if(abs(d) < GROUND_STEP) {
                             # enter chopper
-                            player["carry"] := player["carry"] + 1;
+                            player.carry += 1;
                             soldiers[i] := -1000;
                         } else {
                             # move towards chopper
                             if(d < 0) {
-                                soldiers[i] := soldiers[i] + 0.1;
+                                soldiers[i] += 0.1;
                             } else {
-                                soldiers[i] := soldiers[i] - 0.1;
+                                soldiers[i] -= 0.1;
                             }
                         }
                     }                    
                 }
             }
-            i := i + 1;
+            i += 1;
         }
         soldierMoveTimer := getTicks() + 0.01;
     }
@@ -410,15 +415,15 @@ def moveSoldiers() {
 def drawUI() {
     drawText(0, 0, COLOR_LIGHT_BLUE, COLOR_DARK_BLUE, "FUEL:");
     color := COLOR_GREEN;
-    if(player["fuel"] < 50) {
+    if(player.fuel < 50) {
         color := COLOR_YELLOW;
     }
-    if(player["fuel"] < 20) {
+    if(player.fuel < 20) {
         color := COLOR_RED;
     }
-    fillRect(40, 3, 40 + (160 - 44) * (player["fuel"]/100), 5, color);
-    drawText(0, 10, COLOR_LIGHT_BLUE, COLOR_DARK_BLUE, "LIFE:" + player["lives"]);
-    drawText(160 - 70 - 2, 10, COLOR_LIGHT_BLUE, COLOR_DARK_BLUE, "CARRY:" + player["carry"] + "/4");
+    fillRect(40, 3, 40 + (160 - 44) * (player.fuel/100), 5, color);
+    drawText(0, 10, COLOR_LIGHT_BLUE, COLOR_DARK_BLUE, "LIFE:" + player.lives);
+    drawText(160 - 70 - 2, 10, COLOR_LIGHT_BLUE, COLOR_DARK_BLUE, "CARRY:" + player.carry + "/4");
 }
 
 def drawTitle() {
@@ -427,14 +432,14 @@ def drawTitle() {
     drawText(14, 35, COLOR_MID_GRAY, COLOR_BLACK, "for the Benji4000");
     drawText(25, 160, COLOR_MID_GRAY, COLOR_BLACK, "SPACE to start");
     drawText(14, 175, COLOR_DARK_GRAY, COLOR_BLACK, "2020 (c) by Gabor");
-    player["x"] := 80;
-    player["y"] := 100;
-    player["dir"] := -1;
+    player.x := 80;
+    player.y := 100;
+    player.dir := -1;
     drawPlayerHealthy();
     if(isKeyDown(KeySpace)) {
-        player["x"] := 30 * GROUND_STEP;
-        player["y"] := 100;
-        player["dir"] := 0;
+        player.x := 30 * GROUND_STEP;
+        player.y := 100;
+        player.dir := 0;
         title := false;
         while(isKeyDown(KeySpace)) {
         }
@@ -455,9 +460,9 @@ def drawInfo() {
     drawText(14, 125, COLOR_MID_GRAY, COLOR_BLACK, "Good luck! Press");
     drawText(14, 135, COLOR_MID_GRAY, COLOR_BLACK, "SPACE to begin");
     if(isKeyDown(KeySpace)) {
-        player["x"] := 30 * GROUND_STEP;
-        player["y"] := 100;
-        player["dir"] := 0;
+        player.x := 30 * GROUND_STEP;
+        player.y := 100;
+        player.dir := 0;
         setBackground(COLOR_DARK_BLUE);
         info := false;
     }
@@ -476,8 +481,8 @@ def main() {
             if(info) {
                 drawInfo();
             } else {
-                if(player["lives"] > 0) {        
-                    if(player["saved"] < len(soldiers)) {
+                if(player.lives > 0) {        
+                    if(player.saved < len(soldiers)) {
                         handleInput();
                         movePlayer();
                         moveSoldiers();
@@ -487,11 +492,11 @@ def main() {
                     } else {
                         fillRect(40, 60, 120, 140, COLOR_GREEN);
                         drawText(45, 87, COLOR_BLACK, COLOR_GREEN, "Congrats!");
-                        if(player["killed"] = 0) {
+                        if(player.killed = 0) {
                             drawText(45, 97, COLOR_BLACK, COLOR_GREEN, "Game Won!");
                         } else {
-                            drawText(45, 97, COLOR_BLACK, COLOR_GREEN, "Killed: " + player["killed"]);
-                            drawText(45, 107, COLOR_BLACK, COLOR_GREEN, "Saved: " + player["saved"]);
+                            drawText(45, 97, COLOR_BLACK, COLOR_GREEN, "Killed: " + player.killed);
+                            drawText(45, 107, COLOR_BLACK, COLOR_GREEN, "Saved: " + player.saved);
                         }
                     }
                 } else {
@@ -351,14 +351,13 @@ def get_choice(choices) {
                 print("2: " + choices[1]);
                 print("3: " + choices[2]);
                 ans := input("> ");
-                if(ans = "1") {
-                        return 0;
-                }
-                if(ans = "2") {
-                        return 1;
-                }
-                if(ans = "3") {
-                        return 2;
+                switch(ans) {
+                        case "1":
+                                return 0;
+                        case "2":
+                                return 1;
+                        case "3":
+                                return 2;
                 }
                 print("You must answer 1, 2, or 3");
         }
@@ -2,7 +2,7 @@ def fillScreen(w, h) {
     index := 0;
     c := 0;
     while(index < w * h) {
-        y := index / w;
+        y := index // w;
         x := index % w;
         setPixel(x, y, c);
         c := random() * 16;
@@ -0,0 +1,77 @@
+# dot notation on maps and compound assignments
+
+struct Point { x, y }
+
+calls := 0;
+
+def nextIndex() {
+    calls += 1;
+    return 1;
+}
+
+def main() {
+    # m.key is m["key"]
+    player := { "x": 1, "pos": { "y": 2 } };
+    assert(player.x, 1);
+    assert(player.pos.y, 2);
+    player.x := 5;
+    assert(player["x"], 5);
+    player.pos["y"] := 6;
+    assert(player.pos.y, 6);
+    player.name := "benji";
+    assert(player["name"], "benji");
+    del player.name;
+    assert(len(keys(player)), 2);
+    assert(player.missing, null);
+
+    # compound assignment on variables
+    a := 10;
+    a += 5;
+    assert(a, 15);
+    a -= 3;
+    assert(a, 12);
+    a *= 2;
+    assert(a, 24);
+    a %= 10;
+    assert(a, 4);
+    a /= 8;
+    assert(a, 0.5);
+    s := "Hello";
+    s += " World";
+    assert(s, "Hello World");
+
+    # on array elements, map entries and struct fields
+    arr := [1, 2, 3];
+    arr[2] += 10;
+    assert(arr[2], 13);
+    player.x -= 1;
+    assert(player.x, 4);
+    player["pos"]["y"] *= 2;
+    assert(player.pos.y, 12);
+    p := Point(1, 2);
+    p.y += p.x;
+    assert(p.y, 3);
+
+    # the target is evaluated once
+    arr[nextIndex()] += 1;
+    assert(arr[1], 3);
+    assert(calls, 1);
+
+    failed := false;
+    try {
+        unknown += 1;
+    } catch(e) {
+        failed := true;
+    }
+    assert(failed, true);
+
+    failed := false;
+    try {
+        n := arr.x;
+    } catch(e) {
+        failed := true;
+        assert(e["message"], "Only maps and structs have fields: can't use .x");
+    }
+    assert(failed, true);
+    print("Assignments ok");
+}
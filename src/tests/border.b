@@ -0,0 +1,43 @@
+# the border around the display
+
+def main() {
+    assert(peek(BORDER_ADDRESS), COLOR_LIGHT_BLUE);
+    setBorder(COLOR_RED);
+    assert(peek(BORDER_ADDRESS), COLOR_RED);
+    poke(BORDER_ADDRESS, COLOR_BLACK);
+    assert(peek(BORDER_ADDRESS), COLOR_BLACK);
+
+    # the border is drawn around the display, and doesn't cover it
+    setVideoMode(1);
+    setBackground(COLOR_WHITE);
+    clearVideo();
+    setBorder(COLOR_YELLOW);
+    updateVideo();
+    yellow := getPaletteColor(COLOR_YELLOW);
+    white := getPaletteColor(COLOR_WHITE);
+    assert(getFramePixel(-1, 0), yellow);
+    assert(getFramePixel(0, -1), yellow);
+    assert(getFramePixel(320, 199), yellow);
+    assert(getFramePixel(319, 200), yellow);
+    assert(getFramePixel(-32, -32), yellow);
+    assert(getFramePixel(351, 231), yellow);
+    assert(getFramePixel(0, 0), white);
+    assert(getFramePixel(319, 199), white);
+    assert(getFramePixel(160, 100), white);
+
+    # the frame ends at the border's edge
+    failed := false;
+    try {
+        getFramePixel(-33, 0);
+    } catch(e) {
+        failed := true;
+        assert(e.message, "no pixel at -33,0, the frame is -32,-32 to 351,231");
+    }
+    assert(failed, true);
+
+    setBorder(COLOR_LIGHT_BLUE);
+    setBackground(COLOR_LIGHT_BLUE);
+    clearVideo();
+    setVideoMode(0);
+    print("Border ok");
+}
@@ -0,0 +1,30 @@
+# text mode keeps the colors of each char in color memory
+
+def main() {
+    setVideoMode(0);
+    clearVideo();
+    drawText(2, 3, COLOR_WHITE, COLOR_BLACK, "Hi");
+    assert(getCell(2, 3), { "char": 72, "fg": COLOR_WHITE, "bg": COLOR_BLACK });
+    cell := getCell(3, 3);
+    assert(cell.char, 105);
+
+    # recolor without redrawing
+    setCellColor(2, 3, COLOR_RED, COLOR_YELLOW);
+    assert(getCell(2, 3), { "char": 72, "fg": COLOR_RED, "bg": COLOR_YELLOW });
+
+    # scrolling moves both the chars and their colors
+    scroll(8, -8);
+    assert(getCell(3, 2), { "char": 72, "fg": COLOR_RED, "bg": COLOR_YELLOW });
+    cell := getCell(2, 3);
+    assert(cell.char, 0);
+
+    failed := false;
+    try {
+        getCell(40, 0);
+    } catch(e) {
+        failed := true;
+        assert(e.message, "no cell at 40,0, the text screen is 40x25");
+    }
+    assert(failed, true);
+    print("Cells ok");
+}
@@ -0,0 +1,31 @@
+# redefining glyphs and loading charsets
+
+def main() {
+    # "A" in the standard font
+    assert(getGlyph(65), [12, 30, 51, 51, 63, 51, 51, 0]);
+
+    smiley := [60, 66, 165, 129, 165, 153, 66, 60];
+    setGlyph(65, smiley);
+    assert(getGlyph(65), smiley);
+
+    # 8 bytes per glyph, starting at glyph 0
+    assert(loadCharset("charset.bin"), 2);
+    assert(getGlyph(0), [24, 60, 126, 255, 255, 126, 60, 24]);
+    assert(getGlyph(1), [1, 2, 4, 8, 16, 32, 64, 128]);
+    assert(getGlyph(65), smiley);
+
+    # a sheet of 8x8 glyphs, bit 0 is the leftmost pixel
+    assert(loadCharset("charset.png"), 2);
+    assert(getGlyph(0), [1, 1, 1, 1, 1, 1, 1, 1]);
+    assert(getGlyph(1), [1, 2, 4, 8, 16, 32, 64, 128]);
+
+    failed := false;
+    try {
+        setGlyph(512, smiley);
+    } catch(e) {
+        failed := true;
+        assert(e.message, "no glyph 512, the glyphs are 0-511");
+    }
+    assert(failed, true);
+    print("Charset ok");
+}
@@ -0,0 +1,39 @@
+# comments can go anywhere whitespace can
+
+#[
+  a block comment
+  spanning lines
+]#
+
+const SIZE = 3; # after a constant
+
+player := {
+    # where the player starts
+    "x": 10,
+    "y": 20, # at the bottom
+    #[ lives left ]# "lives": 3
+};
+
+def add(a, #[ the first ]# b) {
+    # a comment before a return
+    return a + b; # and after it
+}
+
+def main() {
+    values := [
+        1, # one
+        2,
+        # three
+        3
+    ];
+    assert(len(values), SIZE);
+    assert(add(player.x, #[ inline ]# player.y), 30);
+    assert(player.lives, 3);
+    s := "# not a comment";
+    assert(len(s), 15);
+    if(true) {
+        # a block with only a comment
+    }
+    print("Comments ok");
+    # the end of main
+}
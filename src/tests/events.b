@@ -0,0 +1,53 @@
+# an event-driven program: main() only registers handlers
+
+frames := 0;
+ticks := 0;
+order := [];
+rasters := 0;
+ticker := null;
+frameHandler := null;
+
+def tick() {
+    ticks += 1;
+    if(ticks = 3) {
+        cancel(ticker);
+    }
+}
+
+def frame() {
+    frames += 1;
+}
+
+def raster() {
+    rasters += 1;
+}
+
+def first() {
+    order[len(order)] := "first";
+}
+
+def second() {
+    order[len(order)] := "second";
+}
+
+def finish() {
+    assert(ticks, 3);
+    assert(frames > 3, true);
+    assert(order, ["first", "second"]);
+    assert(rasters > 3, true);
+    # nothing should run after this: the onRaster() handler doesn't keep the program running
+    assert(cancel(frameHandler), true);
+    assert(cancel(frameHandler), false);
+    print("Events ok");
+}
+
+def main() {
+    setFrameRate(100);
+    frameHandler := onFrame(frame);
+    ticker := setInterval(tick, 10);
+    setTimeout(second, 20);
+    setTimeout(first, 0);
+    setTimeout(finish, 100);
+    onRaster(0, raster);
+    assert(frames, 0);
+}
@@ -0,0 +1,104 @@
+# try, catch, finally and throw
+
+def fail(msg) {
+    throw msg;
+}
+
+def nested(n) {
+    i := 0;
+    while(i < 10) {
+        if(i = n) {
+            fail("reached " + i);
+        }
+        i := i + 1;
+    }
+    return i;
+}
+
+def withFinally() {
+    log := [];
+    try {
+        log[len(log)] := "try";
+        return log;
+    } finally {
+        log[len(log)] := "finally";
+    }
+}
+
+def main() {
+    # catch a thrown string
+    caught := null;
+    try {
+        throw "boom";
+    } catch(e) {
+        caught := e;
+    }
+    assert(caught["message"], "boom");
+    assert(caught["value"], "boom");
+    print("caught: " + caught["message"] + " at " + caught["position"]);
+
+    # throws propagate out of while, if and function calls
+    try {
+        nested(3);
+        assert(true, false, "should not get here");
+    } catch(e) {
+        assert(e["message"], "reached 3");
+        assert(len(e["stack"]), 3);
+        assert(e["stack"][2]["function"], "fail");
+    }
+    assert(nested(20), 10);
+
+    # interpreter errors can be caught too
+    a := [1, 2, 3];
+    try {
+        x := a[5];
+    } catch(e) {
+        assert(e["message"], "Index out of bounds");
+        assert(e["value"], null);
+    }
+    try {
+        x := substr(1, 2);
+    } catch(e) {
+        print("builtin error: " + e["message"]);
+    }
+
+    # throwing a map
+    try {
+        throw { "code": 42 };
+    } catch(e) {
+        assert(e["value"]["code"], 42);
+    }
+
+    # finally always runs
+    ran := false;
+    try {
+        try {
+            fail("inner");
+        } finally {
+            ran := true;
+        }
+    } catch(e) {
+        assert(e["message"], "inner");
+    }
+    assert(ran, true);
+    assert(withFinally(), ["try", "finally"]);
+
+    # an empty finally block is still a finally block
+    ran := false;
+    try {
+        ran := true;
+    } finally {
+    }
+    assert(ran, true);
+
+    # re-throw
+    try {
+        try {
+            fail("again");
+        } catch(e) {
+            throw e;
+        }
+    } catch(e) {
+        assert(e["message"], "again");
+    }
+}
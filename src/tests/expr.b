@@ -15,6 +15,25 @@ def main() {
     assert(c, 7);
     assert(d, -1);
     assert(b * d, -5);
+
+    # % is the remainder of //, with the sign of the divisor
+    n := -7;
+    assert(n // 2, -4);
+    assert(n % 2, 1);
+    assert(7 % -2, -1);
+    assert(n % -2, -1);
+    assert((n // 2) * 2 + n % 2, n);
+    assert(n // 3 * 3 + n % 3, n);
+    assert(-7.5 % 2, 0.5);
+    assert(7.5 % -2, -0.5);
+    assert((-7.5 // 2) * 2 + -7.5 % 2, -7.5);
+
+    # powers stay integral while they fit, and become floats when they don't
+    assert(2 ** 62, 4611686018427387904);
+    assert(2 ** 64, 18446744073709551616.0);
+    assert(1 ** 1000000000000, 1);
+    assert(-1 ** 1000000000001, -1);
+    assert(2 ** -1, 0.5);
     
     # booleans
     trace("bb=" + bb);
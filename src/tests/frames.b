@@ -0,0 +1,25 @@
+# every frame shown is counted, sleep() waits on the machine's clock
+
+def main() {
+    start := frameCount();
+    updateVideo();
+    updateVideo(true);
+    waitFrame();
+    assert(frameCount(), start + 2);
+    # print() shows a frame too
+    print("Counting");
+    assert(frameCount(), start + 3);
+
+    t := getTicks();
+    sleep(20);
+    assert(getTicks() - t >= 0.02, true);
+
+    failed := false;
+    try {
+        sleep("a while");
+    } catch(e) {
+        failed := true;
+    }
+    assert(failed, true);
+    print("Frames ok");
+}
@@ -0,0 +1,99 @@
+# generators and coroutines
+
+def countTo(n) {
+    i := 1;
+    while(i <= n) {
+        yield i;
+        i += 1;
+    }
+    return "done";
+}
+
+def fib() {
+    a := 0;
+    b := 1;
+    while(true) {
+        yield a;
+        next := a + b;
+        a := b;
+        b := next;
+    }
+}
+
+positions := [];
+
+def patrol(name, steps) {
+    x := 0;
+    while(x < steps) {
+        x += 1;
+        positions[len(positions)] := name + x;
+        yield;
+    }
+}
+
+def failing() {
+    yield 1;
+    throw "broken";
+}
+
+def main() {
+    g := countTo(3);
+    assert(done(g), false);
+    assert(next(g), 1);
+    assert(next(g), 2);
+    assert(next(g), 3);
+    assert(done(g), false);
+    # the return value comes last
+    assert(next(g), "done");
+    assert(done(g), true);
+    assert(next(g), null);
+
+    # generators of the same function have their own variables
+    f1 := fib();
+    f2 := fib();
+    values := [];
+    i := 0;
+    while(i < 8) {
+        values[i] := next(f1);
+        i += 1;
+    }
+    assert(values, [0, 1, 1, 2, 3, 5, 8, 13]);
+    assert(next(f2), 0);
+
+    # anonymous generators
+    squares := (n) => {
+        k := 0;
+        while(k < n) {
+            yield k * k;
+            k += 1;
+        }
+    };
+    s := squares(3);
+    next(s);
+    next(s);
+    assert(next(s), 4);
+
+    # coroutines are resumed once per updateVideo
+    spawn(patrol("a", 2));
+    spawn(patrol("b", 3));
+    updateVideo();
+    assert(positions, ["a1", "b1"]);
+    updateVideo();
+    updateVideo();
+    updateVideo();
+    assert(positions, ["a1", "b1", "a2", "b2", "b3"]);
+
+    # errors in a generator reach the caller of next()
+    e := failing();
+    next(e);
+    failed := false;
+    try {
+        next(e);
+    } catch(err) {
+        failed := true;
+        assert(err["value"], "broken");
+    }
+    assert(failed, true);
+    assert(done(e), true);
+    print("Generators ok");
+}
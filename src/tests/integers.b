@@ -0,0 +1,61 @@
+# integers and floats
+
+def main() {
+    # integer arithmetic stays integral
+    assert(7 + 3, 10);
+    assert(7 * 3, 21);
+    assert(7 % 3, 1);
+    assert(-7 % 3, 2);
+    assert(2 ** 10, 1024);
+    assert(3 ** 40, 12157665459056928801.0);
+    assert("count: " + (1 + 2), "count: 3");
+
+    # / always returns a float, // floors
+    assert(7 / 2, 3.5);
+    assert(10 / 5, 2);
+    assert(7 // 2, 3);
+    assert(-7 // 2, -4);
+    assert(7.5 // 2, 3);
+    assert(5.5 % 2, 1.5);
+
+    # mixing integers and floats gives a float
+    assert(1 + 0.5, 1.5);
+    assert(3 = 3.0, true);
+    assert(2 < 2.5, true);
+
+    # hex literals
+    assert(0xff, 255);
+    assert(0x10 | 1, 17);
+
+    # conversions
+    assert(int(2.7), 2);
+    assert(int(-2.7), -2);
+    assert(round(2.5), 3);
+    assert(round(-2.5), -3);
+    assert(float(3) / 2, 1.5);
+    assert(abs(-4), 4);
+    assert(abs(-4.5), 4.5);
+    assert(len("abc") * 2, 6);
+
+    # array indexes must be integers
+    a := [10, 20, 30];
+    assert(a[5 // 2], 30);
+    assert(a[4 / 2], 30);
+    failed := false;
+    try {
+        x := a[3 / 2];
+    } catch(e) {
+        failed := true;
+        assert(e["message"], "Array index should be an integer, not 1.5 (use int() or //)");
+    }
+    assert(failed, true);
+
+    failed := false;
+    try {
+        x := 1 // 0;
+    } catch(e) {
+        failed := true;
+    }
+    assert(failed, true);
+    print("Integers ok");
+}
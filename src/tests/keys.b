@@ -0,0 +1,56 @@
+# the key event queue, fed with simulated key presses
+
+def main() {
+    clearKeys();
+    assert(getKey(), null);
+    assert(isKeyDown(KeyA), false);
+
+    # events come out in the order they happened, with their modifiers
+    simulateKey(KeyA, KeyPress, ModShift);
+    simulateKey(KeyA, KeyRepeat, ModShift);
+    simulateKey(KeyB, KeyPress);
+    simulateKey(KeyA, KeyRelease);
+    assert(isKeyDown(KeyA), false);
+    assert(isKeyDown(KeyB), true);
+
+    event := getKey();
+    assert(event.key, KeyA);
+    assert(event.action, KeyPress);
+    assert(event.mods, ModShift);
+    event := getKey();
+    assert(event.action, KeyRepeat);
+    assert(event.mods, ModShift);
+    event := getKey();
+    assert(event.key, KeyB);
+    assert(event.mods, 0);
+    event := getKey();
+    assert(event.key, KeyA);
+    assert(event.action, KeyRelease);
+    assert(getKey(), null);
+
+    # modifiers are or-ed together
+    simulateKey(KeyC, KeyPress, ModControl | ModAlt);
+    event := waitKey();
+    assert(event.mods & ModAlt, ModAlt);
+    assert(event.mods & ModShift, 0);
+
+    # clearKeys() forgets the queue, but not which keys are down
+    simulateKey(KeyD, KeyPress);
+    simulateKey(KeyE, KeyPress);
+    clearKeys();
+    assert(getKey(), null);
+    assert(isKeyDown(KeyD), true);
+    simulateKey(KeyD, KeyRelease);
+    simulateKey(KeyE, KeyRelease);
+    simulateKey(KeyB, KeyRelease);
+    clearKeys();
+
+    failed := false;
+    try {
+        simulateKey(KeyA, 7);
+    } catch(e) {
+        failed := true;
+    }
+    assert(failed, true);
+    print("Keys ok");
+}
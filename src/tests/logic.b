@@ -0,0 +1,42 @@
+# short-circuit boolean operators and the ternary operator
+
+calls := 0;
+
+def touch(b) {
+    calls := calls + 1;
+    return b;
+}
+
+def main() {
+    a := [1, 0, 3];
+
+    # the rhs is not evaluated when the lhs decides the result
+    i := 5;
+    assert(i < len(a) && a[i] = 0, false);
+    m := { "x": 1 };
+    assert(m["y"] = null || m["y"] > 0, true);
+
+    calls := 0;
+    assert(false && touch(true), false);
+    assert(true || touch(false), true);
+    assert(calls, 0);
+    assert(true && touch(true), true);
+    assert(false || touch(false), false);
+    assert(calls, 2);
+
+    # && binds tighter than ||
+    assert(true || false && false, true);
+    assert(false && true || true, true);
+    assert((true || false) && false, false);
+
+    # ternary
+    i := 1;
+    assert(i < len(a) ? a[i] : -1, 0);
+    i := 7;
+    assert(i < len(a) ? a[i] : -1, -1);
+    assert(i > 5 ? "big" : "small", "big");
+    assert(i > 10 ? "huge" : i > 5 ? "big" : "small", "big");
+    s := "lives: " + (i = 1 ? "one" : "many");
+    assert(s, "lives: many");
+    print(s);
+}
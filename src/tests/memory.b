@@ -0,0 +1,62 @@
+# the machine's memory, see docs/memory-map.md
+
+def main() {
+    # the free memory can hold anything
+    poke(FREE_ADDRESS, 42);
+    assert(peek(FREE_ADDRESS), 42);
+    poke(0xffff, 255);
+    assert(peek(0xffff), 255);
+
+    # the background color register
+    setBackground(COLOR_RED);
+    assert(peek(BACKGROUND_ADDRESS), COLOR_RED);
+    poke(BACKGROUND_ADDRESS, COLOR_BLACK);
+    setVideoMode(1);
+    clearVideo();
+    assert(getPixel(0, 0), COLOR_BLACK);
+
+    # two pixels per byte, the left one in the low 4 bits
+    setPixel(0, 0, COLOR_WHITE);
+    assert(peek(VIDEO_ADDRESS), COLOR_WHITE + COLOR_BLACK * 16);
+    setPixel(9, 0, COLOR_RED);
+    assert(peek(VIDEO_ADDRESS + 4), COLOR_BLACK + COLOR_RED * 16);
+    poke(VIDEO_ADDRESS + 1, COLOR_GREEN);
+    assert(getPixel(2, 0), COLOR_GREEN);
+
+    # text and color memory
+    setVideoMode(0);
+    drawFont(1, 0, COLOR_WHITE, COLOR_BLACK, 300);
+    assert(peek(TEXT_ADDRESS + 2), 300 & 0xff);
+    assert(peek(TEXT_ADDRESS + 3), 300 >> 8);
+    poke(COLOR_ADDRESS + 1, COLOR_RED + COLOR_YELLOW * 16);
+    assert(getCell(1, 0), { "char": 300, "fg": COLOR_RED, "bg": COLOR_YELLOW });
+
+    # the font
+    poke(FONT_ADDRESS + 65 * 8, 255);
+    glyph := getGlyph(65);
+    assert(glyph[0], 255);
+
+    # the palette: white is color 1
+    assert(peek(PALETTE_ADDRESS + 3), 255);
+
+    # the sprite registers are reserved between the machine's registers and the free memory
+    assert(SPRITE_ADDRESS > BORDER_ADDRESS && SPRITE_ADDRESS + 8 * 8 <= FREE_ADDRESS, true);
+
+    failed := false;
+    try {
+        poke(0x10000, 1);
+    } catch(e) {
+        failed := true;
+        assert(e.message, "no address 65536, the memory is 0-65535");
+    }
+    assert(failed, true);
+    failed := false;
+    try {
+        poke(0, 256);
+    } catch(e) {
+        failed := true;
+        assert(e.message, "a byte is 0-255, not 256");
+    }
+    assert(failed, true);
+    print("Memory ok");
+}
@@ -0,0 +1,83 @@
+# the mouse, fed with simulated events
+
+def main() {
+    clearMouse();
+    assert(getMouse(), null);
+    assert(mouseX(), 0);
+    assert(mouseY(), 0);
+    assert(isMouseDown(MouseLeft), false);
+
+    # moves, presses and wheel turns are queued in order
+    simulateMouseMove(10, 20);
+    assert(mouseX(), 10);
+    assert(mouseY(), 20);
+    simulateMouseButton(MouseLeft, true);
+    assert(isMouseDown(MouseLeft), true);
+    assert(isMouseDown(MouseRight), false);
+    simulateMouseWheel(0, -1);
+    simulateMouseButton(MouseLeft, false);
+    assert(isMouseDown(MouseLeft), false);
+
+    event := getMouse();
+    assert(event.type, MouseMove);
+    assert(event.x, 10);
+    assert(event.y, 20);
+    event := getMouse();
+    assert(event.type, MousePress);
+    assert(event.button, MouseLeft);
+    assert(event.x, 10);
+    event := getMouse();
+    assert(event.type, MouseWheel);
+    assert(event.dy, -1);
+    event := getMouse();
+    assert(event.type, MouseRelease);
+    assert(getMouse(), null);
+
+    # only the last of several moves in a row is kept
+    simulateMouseMove(1, 1);
+    simulateMouseMove(2, 2);
+    event := getMouse();
+    assert(event.x, 2);
+    assert(getMouse(), null);
+
+    # positions off the screen are clamped
+    simulateMouseMove(-5, 500);
+    assert(mouseX(), 0);
+    assert(mouseY(), 199);
+
+    # window positions: the window shows the 384x264 frame (the screen and a 32 pixel border) at any size
+    simulateMouseMove(84, 104, 768, 528);
+    assert(mouseX(), 10);
+    assert(mouseY(), 20);
+    # resized to half the size
+    simulateMouseMove(42, 52, 384, 264);
+    assert(mouseX(), 10);
+    assert(mouseY(), 20);
+    # stretched wide
+    simulateMouseMove(168, 104, 1536, 528);
+    assert(mouseX(), 10);
+    assert(mouseY(), 20);
+    # on the border, the position is the nearest edge of the screen
+    simulateMouseMove(0, 0, 768, 528);
+    assert(mouseX(), 0);
+    assert(mouseY(), 0);
+    simulateMouseMove(767, 527, 768, 528);
+    assert(mouseX(), 319);
+    assert(mouseY(), 199);
+
+    # multicolor pixels are double wide
+    setVideoMode(2);
+    simulateMouseMove(84, 104, 768, 528);
+    assert(mouseX(), 5);
+    simulateMouseMove(767, 104, 768, 528);
+    assert(mouseX(), 159);
+    simulateMouseMove(30, 5);
+    assert(mouseX(), 30);
+    setVideoMode(0);
+    assert(mouseX(), 60);
+
+    clearMouse();
+    simulateMouseMove(0, 0);
+    clearMouse();
+    print("Mouse ok");
+}
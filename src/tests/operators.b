@@ -0,0 +1,55 @@
+# unary and bitwise operators
+
+def neg(n) {
+    return -n;
+}
+
+def main() {
+    a := 5;
+    b := 3;
+
+    # negation of any value
+    assert(-a, -5);
+    assert(-(a + b), -8);
+    assert(- -a, 5);
+    assert(neg(a) * 2, -10);
+    assert(a - -b, 8);
+
+    # logical not
+    assert(!true, false);
+    assert(!(a > b), false);
+    assert(!(a = b) && true, true);
+
+    # powers
+    assert(2 ** 3, 8);
+    assert(2 ** 3 * 2, 16);
+    assert(-2 ** 2, -4);
+
+    # bitwise operators
+    assert(12 & 10, 8);
+    assert(12 | 10, 14);
+    assert(12 ^ 10, 6);
+    assert(1 << 4, 16);
+    assert(256 >> 2, 64);
+    assert(~0, -1);
+    assert(~a & 7, 2);
+
+    # precedence: shift binds tighter than &, & tighter than ^, ^ tighter than |
+    assert(1 | 2 ^ 3 & 1 << 1, 1);
+    assert(((1 | 2) ^ 3) & 1 << 1, 0);
+    assert(1 + 1 << 2, 8);
+    assert(a & 1 = 1, true);
+    assert(3 | 4 > 6 || false, true);
+
+    # sprite mask
+    row := 0;
+    x := 0;
+    while(x < 8) {
+        if(x % 2 = 0) {
+            row := row | (1 << x);
+        }
+        x := x + 1;
+    }
+    assert(row, 85);
+    print("mask=" + row);
+}
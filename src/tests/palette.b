@@ -0,0 +1,44 @@
+# changing, cycling and switching palettes
+
+def main() {
+    resetPalette();
+    assert(getPaletteColor(COLOR_WHITE), [255, 255, 255]);
+
+    setPaletteColor(COLOR_RED, 1, 2, 3);
+    assert(getPaletteColor(COLOR_RED), [1, 2, 3]);
+    assert(peek(PALETTE_ADDRESS + COLOR_RED * 3 + 2), 3);
+
+    # cycling moves each color up by step, the last one wraps around
+    black := getPaletteColor(0);
+    white := getPaletteColor(1);
+    red := getPaletteColor(2);
+    cyclePalette(0, 2, 1);
+    assert(getPaletteColor(0), red);
+    assert(getPaletteColor(1), black);
+    assert(getPaletteColor(2), white);
+    cyclePalette(0, 2, -1);
+    assert(getPaletteColor(0), black);
+    assert(getPaletteColor(2), red);
+
+    resetPalette();
+    red := getPaletteColor(COLOR_RED);
+    assert(red[0], 0x88);
+
+    setPalette("cga");
+    assert(getPaletteColor(COLOR_DARK_BLUE), [0, 0, 170]);
+    setPalette("grayscale");
+    gray := getPaletteColor(COLOR_RED);
+    assert(gray[0] = gray[1] && gray[1] = gray[2], true);
+    setPalette("pepto");
+    resetPalette();
+
+    failed := false;
+    try {
+        setPalette("vga");
+    } catch(e) {
+        failed := true;
+        assert(e.message, "no palette \"vga\", the palettes are: cga, default, grayscale, pepto");
+    }
+    assert(failed, true);
+    print("Palette ok");
+}
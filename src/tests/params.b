@@ -0,0 +1,59 @@
+# default, rest and spread parameters
+
+def box(x, y, w = 10, h = w) {
+    return [x, y, w, h];
+}
+
+def sum(first, ...rest) {
+    total := first;
+    i := 0;
+    while(i < len(rest)) {
+        total += rest[i];
+        i += 1;
+    }
+    return total;
+}
+
+def count(...items) {
+    return len(items);
+}
+
+def expectError(f, message) {
+    failed := false;
+    try {
+        f();
+    } catch(e) {
+        failed := true;
+        assert(e["message"], message);
+    }
+    assert(failed, true);
+}
+
+def main() {
+    # defaults are evaluated at call time and can use the parameters before them
+    assert(box(1, 2), [1, 2, 10, 10]);
+    assert(box(1, 2, 5), [1, 2, 5, 5]);
+    assert(box(1, 2, 5, 6), [1, 2, 5, 6]);
+
+    # the rest parameter is an array of the remaining arguments
+    assert(sum(1), 1);
+    assert(sum(1, 2, 3), 6);
+    assert(count(), 0);
+    anonCount := (...a) => len(a);
+    assert(anonCount(1, 2), 2);
+
+    # spread an array into arguments
+    args := [1, 2, 3, 4];
+    assert(sum(...args), 10);
+    assert(sum(10, ...args, 100), 120);
+    assert(box(...[1, 2]), [1, 2, 10, 10]);
+    assert(substr(...["Hello", 1, 2]), "el");
+
+    # wrong argument counts are reported the same way for functions and builtins
+    expectError(() => box(1), "box() takes 2 to 4 arguments, not 1");
+    expectError(() => sum(), "sum() takes at least 1 argument(s), not 0");
+    expectError(() => len(), "len() takes 1 argument(s), not 0");
+    expectError(() => substr("a", 1, 2, 3), "substr() takes 2 to 3 arguments, not 4");
+    expectError(() => sum(...5), "only an array can be spread into arguments, not 5");
+    print("Params ok");
+}
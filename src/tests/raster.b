@@ -0,0 +1,87 @@
+# raster handlers: a text status bar above a hires playfield
+
+lines := [];
+top := null;
+split := null;
+
+def topOfFrame() {
+    lines[len(lines)] := -32;
+    setVideoMode(0);
+    setBorder(COLOR_LIGHT_BLUE);
+    setScrollOffset(0, 0);
+}
+
+def playfield() {
+    lines[len(lines)] := 16;
+    setVideoMode(1);
+    setBorder(COLOR_BLACK);
+    setScrollOffset(4, 0);
+}
+
+def redraw() {
+    updateVideo();
+}
+
+def main() {
+    setBackground(COLOR_WHITE);
+    setVideoMode(1);
+    clearVideo();
+    setPixel(5, 5, COLOR_RED);
+    setPixel(5, 50, COLOR_RED);
+
+    top := onRaster(-32, topOfFrame);
+    split := onRaster(16, playfield);
+
+    updateVideo();
+    assert(lines, [-32, 16]);
+    # each part of the frame is drawn the way its handler set it up
+    white := getPaletteColor(COLOR_WHITE);
+    red := getPaletteColor(COLOR_RED);
+    assert(getFramePixel(-1, 15), getPaletteColor(COLOR_LIGHT_BLUE));
+    assert(getFramePixel(5, 5), white);
+    assert(getFramePixel(-1, 16), getPaletteColor(COLOR_BLACK));
+    assert(getFramePixel(1, 50), red);
+    assert(getFramePixel(5, 50), white);
+    # the state set by the last handler stays until the next frame
+    assert(peek(BORDER_ADDRESS), COLOR_BLACK);
+    assert(peek(SCROLL_ADDRESS), 4);
+
+    updateVideo();
+    assert(lines, [-32, 16, -32, 16]);
+
+    # the scroll offset wraps around the display
+    setScrollOffset(-1, 201);
+    assert(peek(SCROLL_ADDRESS) + peek(SCROLL_ADDRESS + 1) * 256, 319);
+    assert(peek(SCROLL_ADDRESS + 2), 1);
+
+    failed := false;
+    try {
+        onRaster(232, playfield);
+    } catch(e) {
+        failed := true;
+        assert(e.message, "First argument to onRaster() should be a line from -32 to 231");
+    }
+    assert(failed, true);
+
+    # a frame can't be shown while it's being drawn
+    nested := onRaster(100, redraw);
+    failed := false;
+    try {
+        updateVideo();
+    } catch(e) {
+        failed := true;
+        assert(e.message, "updateVideo() can't be called from an onRaster() handler");
+    }
+    assert(failed, true);
+    cancel(nested);
+
+    # nothing keeps the event loop running after this
+    cancel(top);
+    cancel(split);
+    setScrollOffset(0, 0);
+    setVideoMode(0);
+    setBorder(COLOR_LIGHT_BLUE);
+    setBackground(COLOR_LIGHT_BLUE);
+    clearVideo();
+    print("Raster ok");
+}
@@ -0,0 +1,43 @@
+# reading the screen back, in the coordinates of each video mode
+
+def main() {
+    setVideoMode(1);
+    setBackground(COLOR_BLACK);
+    clearVideo();
+    setPixel(319, 199, COLOR_RED);
+    assert(getPixel(319, 199), COLOR_RED);
+    assert(getPixel(0, 0), COLOR_BLACK);
+    screen := getScreen();
+    assert(len(screen), 320 * 200);
+    assert(screen[199 * 320 + 319], COLOR_RED);
+
+    # multicolor pixels are double wide
+    setVideoMode(2);
+    clearVideo();
+    setPixel(159, 10, COLOR_GREEN);
+    assert(getPixel(159, 10), COLOR_GREEN);
+    assert(len(getScreen()), 160 * 200);
+
+    failed := false;
+    try {
+        getPixel(160, 0);
+    } catch(e) {
+        failed := true;
+        assert(e.message, "no pixel at 160,0, the screen is 160x200");
+    }
+    assert(failed, true);
+
+    # in text mode the pixels come from the chars
+    setVideoMode(0);
+    clearVideo();
+    drawFont(1, 1, COLOR_WHITE, COLOR_DARK_BLUE, 65);
+    assert(getChar(1, 1), 65);
+    glyph := getGlyph(65);
+    x := 0;
+    while((glyph[0] >> x) & 1 = 0) {
+        x += 1;
+    }
+    assert(getPixel(8 + x, 8), COLOR_WHITE);
+    assert(getPixel(8, 15), COLOR_DARK_BLUE);
+    print("Readback ok");
+}
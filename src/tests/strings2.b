@@ -0,0 +1,38 @@
+# string escapes, interpolation and multi-line strings
+
+const NAME = "Benji";
+
+def double(n) {
+    return n * 2;
+}
+
+def main() {
+    # escapes
+    assert(len("a\nb"), 3);
+    assert(len("tab\there"), 8);
+    assert("say \"hi\"", "say " + "\"hi\"");
+    assert(len("back\\slash"), 10);
+    assert("\x41\x42", "AB");
+    assert("\u{43}", "C");
+    assert(len("\$1"), 2);
+
+    # interpolation in the current closure
+    lives := 3;
+    player := { "name": "Zed", "score": 120 };
+    assert("Lives: ${lives}", "Lives: 3");
+    assert("${player["name"]} has ${player["score"]} points", "Zed has 120 points");
+    assert("twice: ${double(lives)}", "twice: 6");
+    assert("${lives > 2 ? "many" : "few"} lives", "many lives");
+    assert("Hello ${NAME}!", "Hello Benji!");
+    assert("not interpolated: \${lives}", "not interpolated: " + "$" + "{lives}");
+    key := "k";
+    m := { "${key}1": 1 };
+    assert(m["k1"], 1);
+
+    # triple-quoted strings span lines
+    desc := """
+You are in a dark room.
+A "door" leads ${lives} ways.""";
+    assert(desc, "You are in a dark room.\nA \"door\" leads 3 ways.");
+    print(desc);
+}
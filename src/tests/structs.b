@@ -0,0 +1,65 @@
+# struct declarations
+
+struct Player { x, y, lives }
+struct Game { player, level }
+
+const START = Player(10, 20, 3);
+
+game := Game(Player(0, 0, 3), 1);
+
+def move(p, dx, dy) {
+    p.x := p.x + dx;
+    p.y := p.y + dy;
+}
+
+def main() {
+    p := Player(1, 2, 3);
+    assert(p.x, 1);
+    assert(p.y, 2);
+    assert(p.lives, 3);
+
+    # structs are passed by reference, like maps
+    move(p, 5, 5);
+    assert(p.x, 6);
+    assert(p.y, 7);
+
+    # nested access
+    game.player.lives := game.player.lives - 1;
+    assert(game.player.lives, 2);
+    players := [Player(1, 1, 1), Player(2, 2, 2)];
+    players[1].x := 5;
+    assert(players[1].x, 5);
+    assert(START.lives, 3);
+
+    # printing and equality
+    assert("" + p, "Player{x: 6, y: 7, lives: 3}");
+    assert(Player(1, 2, 3), Player(1, 2, 3));
+
+    failed := false;
+    try {
+        p.z := 1;
+    } catch(e) {
+        failed := true;
+        assert(e["message"], "struct Player has no field z");
+    }
+    assert(failed, true);
+
+    failed := false;
+    try {
+        x := p.lifes;
+    } catch(e) {
+        failed := true;
+        assert(e["message"], "struct Player has no field lifes");
+    }
+    assert(failed, true);
+
+    failed := false;
+    try {
+        p := Player(1, 2);
+    } catch(e) {
+        failed := true;
+        assert(e["message"], "Player() needs a value for each field: x, y, lives");
+    }
+    assert(failed, true);
+    print("Structs ok");
+}
@@ -0,0 +1,69 @@
+# else if chains and switch statements
+
+const RED = 1;
+const GREEN = 2;
+const BLUE = 3;
+
+def grade(n) {
+    if(n >= 90) {
+        return "A";
+    } else if(n >= 80) {
+        return "B";
+    } else if(n >= 70) {
+        return "C";
+    } else {
+        return "F";
+    }
+}
+
+def colorName(c) {
+    switch(c) {
+        case RED:
+            return "red";
+        case GREEN, BLUE:
+            name := "green";
+            if(c = BLUE) {
+                name := "blue";
+            }
+            return name;
+        default:
+            return "unknown";
+    }
+}
+
+def answer(s) {
+    result := 0;
+    switch(s) {
+        case "yes", "y":
+            result := 1;
+        case "no", "n":
+            result := 2;
+    }
+    return result;
+}
+
+def main() {
+    assert(grade(95), "A");
+    assert(grade(85), "B");
+    assert(grade(75), "C");
+    assert(grade(15), "F");
+
+    assert(colorName(RED), "red");
+    assert(colorName(GREEN), "green");
+    assert(colorName(BLUE), "blue");
+    assert(colorName(7), "unknown");
+
+    assert(answer("y"), 1);
+    assert(answer("no"), 2);
+    assert(answer("maybe"), 0);
+
+    flag := false;
+    switch(flag) {
+        case true:
+            assert(true, false, "should not match");
+        case false:
+            flag := true;
+    }
+    assert(flag, true);
+    print("switch ok");
+}